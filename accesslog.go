@@ -0,0 +1,145 @@
+package hybridlog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Access log formats in Apache's LogFormat syntax
+const (
+	CommonLogFormat   = `%h %l %u %t "%r" %>s %b`
+	CombinedLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// accessRecord is what an access log line is built from
+type accessRecord struct {
+	r        *http.Request
+	status   int
+	bytes    int64
+	start    time.Time
+	duration time.Duration
+	remoteIP string
+}
+
+// accessPart appends one piece of a line
+type accessPart func(b []byte, a *accessRecord) []byte
+
+// parseAccessFormat compiles an Apache LogFormat string. Supported: %h remote
+// IP, %l always "-", %u basic auth user, %t time, %r request line, %s and %>s
+// status, %b and %B bytes ("-" for none with %b), %D microseconds, %T
+// seconds, %m method, %U path, %q query string, %H protocol, %{Name}i request
+// header and %%
+func parseAccessFormat(format string) ([]accessPart, error) {
+	var parts []accessPart
+	literal := func(s string) accessPart {
+		return func(b []byte, _ *accessRecord) []byte { return append(b, s...) }
+	}
+	for len(format) > 0 {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			parts = append(parts, literal(format))
+			break
+		}
+		if i > 0 {
+			parts = append(parts, literal(format[:i]))
+		}
+		format = format[i+1:]
+		if format == "" {
+			return nil, fmt.Errorf("invalid access log format: trailing %%")
+		}
+
+		if format[0] == '{' {
+			end := strings.Index(format, "}")
+			if end < 0 || end+1 >= len(format) || format[end+1] != 'i' {
+				return nil, fmt.Errorf("invalid access log format: only %%{Header}i takes a name")
+			}
+			header := format[1:end]
+			parts = append(parts, func(b []byte, a *accessRecord) []byte {
+				return appendOrDash(b, a.r.Header.Get(header))
+			})
+			format = format[end+2:]
+			continue
+		}
+
+		directive := format[:1]
+		if strings.HasPrefix(format, ">s") {
+			directive = ">s"
+		}
+		format = format[len(directive):]
+		var part accessPart
+		switch directive {
+		case "%":
+			part = literal("%")
+		case "h":
+			part = func(b []byte, a *accessRecord) []byte { return appendOrDash(b, a.remoteIP) }
+		case "l":
+			part = literal("-")
+		case "u":
+			part = func(b []byte, a *accessRecord) []byte {
+				user, _, _ := a.r.BasicAuth()
+				return appendOrDash(b, user)
+			}
+		case "t":
+			part = func(b []byte, a *accessRecord) []byte {
+				return append(a.start.AppendFormat(append(b, '['), "02/Jan/2006:15:04:05 -0700"), ']')
+			}
+		case "r":
+			part = func(b []byte, a *accessRecord) []byte {
+				return append(b, a.r.Method+" "+a.r.RequestURI+" "+a.r.Proto...)
+			}
+		case "s", ">s":
+			part = func(b []byte, a *accessRecord) []byte { return strconv.AppendInt(b, int64(a.status), 10) }
+		case "b":
+			part = func(b []byte, a *accessRecord) []byte {
+				if a.bytes == 0 {
+					return append(b, '-')
+				}
+				return strconv.AppendInt(b, a.bytes, 10)
+			}
+		case "B":
+			part = func(b []byte, a *accessRecord) []byte { return strconv.AppendInt(b, a.bytes, 10) }
+		case "D":
+			part = func(b []byte, a *accessRecord) []byte { return strconv.AppendInt(b, a.duration.Microseconds(), 10) }
+		case "T":
+			part = func(b []byte, a *accessRecord) []byte { return strconv.AppendInt(b, int64(a.duration/time.Second), 10) }
+		case "m":
+			part = func(b []byte, a *accessRecord) []byte { return append(b, a.r.Method...) }
+		case "U":
+			part = func(b []byte, a *accessRecord) []byte { return append(b, a.r.URL.Path...) }
+		case "q":
+			part = func(b []byte, a *accessRecord) []byte {
+				if a.r.URL.RawQuery == "" {
+					return b
+				}
+				return append(append(b, '?'), a.r.URL.RawQuery...)
+			}
+		case "H":
+			part = func(b []byte, a *accessRecord) []byte { return append(b, a.r.Proto...) }
+		default:
+			return nil, fmt.Errorf("invalid access log format: unknown directive %%%s", directive)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func appendOrDash(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	return append(b, s...)
+}
+
+// writeAccessLine writes the access log line of a finished request
+func (c *core) writeAccessLine(a *accessRecord) {
+	line := make([]byte, 0, 256)
+	for _, part := range c.accessFormat {
+		line = part(line, a)
+	}
+	if _, err := c.accessOut.Write(append(line, '\n')); err != nil {
+		c.reportError(err)
+	}
+}
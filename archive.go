@@ -0,0 +1,191 @@
+package hybridlog
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveOptions configures uploading of completed log files to an object
+// store. KeyTemplate placeholders: {host} the host name, {file} the path of
+// the file relative to the log dir, {date} its modification date as
+// 2006-01-02 and {year}, {month}, {day} the same date split up
+type ArchiveOptions struct {
+	Store             ObjectStore   // destination, e.g. &S3Store{...} or &GCSStore{...}
+	KeyTemplate       string        // object key, defaults to "{host}/{file}"
+	Compress          bool          // gzip files before uploading, the local copy becomes the .gz
+	DeleteAfterUpload bool          // remove local files once uploaded
+	ScanInterval      time.Duration // how often the log dir is checked for completed files, defaults to 1m
+	Timeout           time.Duration // per upload, defaults to 5m
+}
+
+// archiver uploads files that are no longer written to: those of past
+// rotation periods and lumberjack's size-rotated backups
+type archiver struct {
+	opts ArchiveOptions
+	c    *core
+	host string
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	uploaded map[string]time.Time // files kept locally, by modification time
+}
+
+func newArchiver(c *core, opts ArchiveOptions) *archiver {
+	if opts.KeyTemplate == "" {
+		opts.KeyTemplate = "{host}/{file}"
+	}
+	if opts.ScanInterval <= 0 {
+		opts.ScanInterval = time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	host, _ := os.Hostname()
+	a := &archiver{
+		opts:     opts,
+		c:        c,
+		host:     host,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		uploaded: map[string]time.Time{},
+	}
+	go a.run()
+	return a
+}
+
+// trigger schedules a scan, called after a rotation
+func (a *archiver) trigger() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *archiver) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.opts.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		a.scan()
+		select {
+		case <-a.wake:
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background scans
+func (a *archiver) Close() {
+	a.once.Do(func() { close(a.stop) })
+	<-a.done
+}
+
+func (a *archiver) scan() {
+	for _, path := range a.c.rotatedFiles() {
+		if a.c.bundler != nil && !strings.HasSuffix(path, ".tar.gz") {
+			continue // uploaded once bundled
+		}
+		if err := a.archive(path); err != nil {
+			a.c.reportError(err) // retried on the next scan
+		}
+	}
+}
+
+// archive uploads one file, compressing and deleting it as configured
+func (a *archiver) archive(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // removed by retention in the meantime
+	}
+	if t, ok := a.uploaded[path]; ok && t.Equal(info.ModTime()) {
+		return nil
+	}
+	if a.opts.Compress && !strings.HasSuffix(path, ".gz") {
+		if path, err = gzipFile(path, a.c.file.perm); err != nil {
+			return err
+		}
+		if info, err = os.Stat(path); err != nil {
+			return err
+		}
+	}
+
+	dir := a.c.file.logDir
+	rel, _ := filepath.Rel(dir, path)
+	mod := info.ModTime()
+	key := strings.NewReplacer(
+		"{host}", a.host,
+		"{file}", filepath.ToSlash(rel),
+		"{date}", mod.Format("2006-01-02"),
+		"{year}", mod.Format("2006"),
+		"{month}", mod.Format("01"),
+		"{day}", mod.Format("02"),
+	).Replace(a.opts.KeyTemplate)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.opts.Timeout)
+	defer cancel()
+	if err := a.opts.Store.Put(ctx, key, file, info.Size()); err != nil {
+		return err
+	}
+
+	if a.opts.DeleteAfterUpload {
+		return os.Remove(path)
+	}
+	a.uploaded[path] = mod
+	return nil
+}
+
+// gzipFile replaces path with path.gz, keeping its modification time
+func gzipFile(path string, perm filePerm) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	dst := path + ".gz"
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = perm.apply(dst, perm.fileMode)
+	}
+	if err == nil {
+		err = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	return dst, os.Remove(path)
+}
@@ -0,0 +1,205 @@
+package hybridlog
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// asyncBatchBytes is the batch size that triggers a write before the flush interval
+const asyncBatchBytes = 64 * 1024
+
+// asyncWriter queues entries in a bounded channel drained by a background goroutine
+type asyncWriter struct {
+	mu       sync.RWMutex // guards closed against sends on a closed channel
+	closed   bool
+	ch       chan []byte
+	flushCh  chan chan struct{}
+	done     chan struct{}
+	drop     bool // drop entries instead of blocking when the queue is full
+	dropped  atomic.Uint64
+	interval time.Duration
+	write    func([]byte) (int, error)
+}
+
+func newAsyncWriter(size int, interval time.Duration, drop bool, write func([]byte) (int, error)) *asyncWriter {
+	if size <= 0 {
+		size = 1024
+	}
+	a := &asyncWriter{
+		ch:       make(chan []byte, size),
+		flushCh:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+		drop:     drop,
+		interval: interval,
+		write:    write,
+	}
+	go a.run()
+	return a
+}
+
+// Write queues a copy of p, logrus reuses its buffer after Write returns
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+	b := append([]byte(nil), p...)
+	if a.drop {
+		select {
+		case a.ch <- b:
+		default:
+			a.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+	a.ch <- b
+	return len(p), nil
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+
+	var buf bytes.Buffer
+	flush := func() {
+		if buf.Len() > 0 {
+			a.write(buf.Bytes())
+			buf.Reset()
+		}
+	}
+
+	var tick <-chan time.Time
+	if a.interval > 0 {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case p, ok := <-a.ch:
+			if !ok {
+				flush()
+				return
+			}
+			buf.Write(p)
+			if tick == nil || buf.Len() >= asyncBatchBytes {
+				flush()
+			}
+		case <-tick:
+			flush()
+		case ack := <-a.flushCh:
+			for n := len(a.ch); n > 0; n-- {
+				buf.Write(<-a.ch)
+			}
+			flush()
+			close(ack)
+		}
+	}
+}
+
+// Flush writes every queued entry and waits for it to complete
+func (a *asyncWriter) Flush() {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return
+	}
+	ack := make(chan struct{})
+	a.flushCh <- ack
+	a.mu.RUnlock()
+	<-ack
+}
+
+// Close drains the queue and stops the background goroutine
+func (a *asyncWriter) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.ch)
+	a.mu.Unlock()
+	<-a.done
+}
+
+// dropReporter logs how many entries a dropping asyncWriter lost
+type dropReporter struct {
+	a        *asyncWriter
+	interval time.Duration
+	last     uint64 // dropped count at the previous summary
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newDropReporter(a *asyncWriter, l *logrus.Logger, interval time.Duration) *dropReporter {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	d := &dropReporter{
+		a:        a,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go d.run(l)
+	return d
+}
+
+func (d *dropReporter) run(l *logrus.Logger) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.summarize(l)
+		case <-d.stop:
+			d.summarize(l)
+			return
+		}
+	}
+}
+
+// summarize logs the entries dropped since the last summary. The summary
+// goes through the same queue and may be dropped too, Dropped still counts
+// the entries it reported
+func (d *dropReporter) summarize(l *logrus.Logger) {
+	total := d.a.dropped.Load()
+	n := total - d.last
+	if n == 0 {
+		return
+	}
+	d.last = total
+
+	period := d.interval.String()
+	if d.interval == time.Minute {
+		period = "minute"
+	}
+	l.WithField("dropped", n).Warnf("dropped %d entries in the last %s, the log queue was full", n, period)
+}
+
+// Close logs a last summary and stops the reporter
+func (d *dropReporter) Close() {
+	d.once.Do(func() { close(d.stop) })
+	<-d.done
+}
+
+// Dropped returns how many entries were dropped because the async queue was
+// full since the logger was created
+func (h *HybridLogger) Dropped() uint64 {
+	if h.async == nil {
+		return 0
+	}
+	return h.async.dropped.Load()
+}
@@ -0,0 +1,60 @@
+package hybridlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AuditOptions configures the audit log, see HybridLogger.Audit
+type AuditOptions struct {
+	File           FileOptions // named <name>-audit<ext> by default, rotated files are kept unless MaxBackups or MaxAgeDays are set
+	RequiredFields []string    // fields every event must have, defaults to actor, action, resource and outcome
+}
+
+// defaultAuditFields are the fields an audit event must have by default
+var defaultAuditFields = []string{"actor", "action", "resource", "outcome"}
+
+// Audit appends event to the audit log as a JSON line and fsyncs it before
+// returning. Audit events skip levels, sampling, filters and hooks and are
+// only written to the audit file. An error is returned if a required field
+// is missing or the event couldn't be made durable
+//
+//	err := log.Audit("user.delete", logrus.Fields{"actor": admin, "action": "delete", "resource": id, "outcome": "success"})
+func (h *HybridLogger) Audit(event string, fields ...logrus.Fields) error {
+	if h.auditFile == nil {
+		return fmt.Errorf("audit log is not enabled")
+	}
+	record := logrus.Fields{}
+	for _, f := range fields {
+		for k, v := range f {
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			record[k] = v
+		}
+	}
+	for _, name := range h.auditFields {
+		if v, ok := record[name]; !ok || v == nil || v == "" {
+			return fmt.Errorf("audit event %q is missing field %q", event, name)
+		}
+	}
+	record["event"] = event
+	record["time"] = time.Now().Format(time.RFC3339Nano)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event %q: %v", event, err)
+	}
+	if _, err := h.auditFile.Write(append(line, '\n')); err != nil {
+		h.reportError(err)
+		return fmt.Errorf("failed to write audit event %q: %v", event, err)
+	}
+	if err := h.auditFile.Sync(); err != nil {
+		h.reportError(err)
+		return fmt.Errorf("failed to sync audit log: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package hybridlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AWSCredentials are the credentials used to sign AWS requests
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero for static credentials
+}
+
+// AWSCredentialsProvider returns credentials for a request, it is called for
+// every request so it should cache them
+type AWSCredentialsProvider func() (AWSCredentials, error)
+
+// StaticAWSCredentials returns a provider for fixed credentials
+func StaticAWSCredentials(accessKeyID, secretAccessKey, sessionToken string) AWSCredentialsProvider {
+	return func() (AWSCredentials, error) {
+		return AWSCredentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey, SessionToken: sessionToken}, nil
+	}
+}
+
+// DefaultAWSCredentials returns a provider reading AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN (as set on Lambda), falling
+// back to the EC2 instance role through IMDSv2
+func DefaultAWSCredentials() AWSCredentialsProvider {
+	var (
+		mu     sync.Mutex
+		cached AWSCredentials
+	)
+	client := &http.Client{Timeout: 2 * time.Second}
+	return func() (AWSCredentials, error) {
+		if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+			return AWSCredentials{
+				AccessKeyID:     id,
+				SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			}, nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached.AccessKeyID != "" && time.Until(cached.Expires) > 5*time.Minute {
+			return cached, nil
+		}
+		creds, err := imdsCredentials(client)
+		if err != nil {
+			return AWSCredentials{}, fmt.Errorf("no AWS credentials in environment or instance metadata: %v", err)
+		}
+		cached = creds
+		return creds, nil
+	}
+}
+
+const imdsURL = "http://169.254.169.254/latest"
+
+func imdsCredentials(client *http.Client) (AWSCredentials, error) {
+	req, _ := http.NewRequest(http.MethodPut, imdsURL+"/api/token", nil)
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := imdsGet(client, req)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	get := func(path string) (string, error) {
+		req, _ := http.NewRequest(http.MethodGet, imdsURL+path, nil)
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return imdsGet(client, req)
+	}
+	role, err := get("/meta-data/iam/security-credentials/")
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	role = strings.TrimSpace(strings.SplitN(role, "\n", 2)[0])
+	body, err := get("/meta-data/iam/security-credentials/" + role)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	var resp struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return AWSCredentials{}, err
+	}
+	return AWSCredentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+		Expires:         resp.Expiration,
+	}, nil
+}
+
+func imdsGet(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("instance metadata: " + resp.Status)
+	}
+	return string(body), nil
+}
+
+// signAWSv4 signs req with AWS Signature Version 4, payloadHash is the hex
+// SHA-256 of the body or UNSIGNED-PAYLOAD
+func signAWSv4(req *http.Request, payloadHash string, creds AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func awsCanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape percent-encodes everything but the RFC 3986 unreserved characters
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,257 @@
+package hybridlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BatchOptions configures how a network output batches entries
+type BatchOptions struct {
+	MaxEntries    int           // entries per request, defaults to 500
+	FlushInterval time.Duration // max time an entry waits for its batch, defaults to 1s
+	QueueSize     int           // entries queued before new ones are dropped, defaults to 10000
+	MaxRetries    int           // retries of a failed request with exponential backoff, defaults to 3, negative disables
+	MaxInFlight   int           // concurrent requests, defaults to 1 which keeps batches in order
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 500
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 10000
+	}
+	if o.MaxInFlight <= 0 {
+		o.MaxInFlight = 1
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	return o
+}
+
+// batchRecord is one formatted entry waiting in a batch
+type batchRecord struct {
+	time  time.Time
+	level logrus.Level
+	line  []byte
+}
+
+// batcher queues records and hands them to send in batches from a background
+// goroutine. Logging never blocks on it: records are dropped when the queue is full
+type batcher struct {
+	mu      sync.RWMutex // guards closed against sends on a closed channel
+	closed  bool
+	opts    BatchOptions
+	ch      chan batchRecord
+	flushCh chan chan error
+	done    chan struct{}
+	send    func([]batchRecord) error
+
+	inFlight chan struct{} // semaphore limiting concurrent requests
+	wg       sync.WaitGroup
+	errMu    sync.Mutex
+	lastErr  error       // last failed request since the previous Flush
+	onError  func(error) // reports failed requests as they happen, may be nil
+}
+
+func newBatcher(opts BatchOptions, send func([]batchRecord) error) *batcher {
+	opts = opts.withDefaults()
+	b := &batcher{
+		opts:    opts,
+		ch:      make(chan batchRecord, opts.QueueSize),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+		send:    send,
+
+		inFlight: make(chan struct{}, opts.MaxInFlight),
+	}
+	go b.run()
+	return b
+}
+
+// WriteLevel queues a copy of p
+func (b *batcher) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	rec := batchRecord{time: time.Now(), level: level, line: append([]byte(nil), bytes.TrimRight(p, "\n")...)}
+	if err := b.enqueue(rec); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// enqueue queues rec, dropping it when the queue is full
+func (b *batcher) enqueue(rec batchRecord) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return errors.New("batch output closed")
+	}
+	select {
+	case b.ch <- rec:
+		return nil
+	default:
+		return errors.New("batch queue full, entry dropped")
+	}
+}
+
+// Write queues p at info level
+func (b *batcher) Write(p []byte) (int, error) {
+	return b.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []batchRecord
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		b.inFlight <- struct{}{}
+		b.wg.Add(1)
+		go func(batch []batchRecord) {
+			defer func() {
+				<-b.inFlight
+				b.wg.Done()
+			}()
+			if err := b.sendWithRetry(batch); err != nil {
+				b.errMu.Lock()
+				b.lastErr = err
+				onError := b.onError
+				b.errMu.Unlock()
+				if onError != nil {
+					onError(err)
+				}
+			}
+		}(batch)
+		batch = nil
+	}
+
+	for {
+		select {
+		case rec, ok := <-b.ch:
+			if !ok {
+				flush()
+				b.wg.Wait()
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= b.opts.MaxEntries {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-b.flushCh:
+			for n := len(b.ch); n > 0; n-- {
+				if batch = append(batch, <-b.ch); len(batch) >= b.opts.MaxEntries {
+					flush()
+				}
+			}
+			flush()
+			b.wg.Wait()
+
+			b.errMu.Lock()
+			err := b.lastErr
+			b.lastErr = nil
+			b.errMu.Unlock()
+			ack <- err
+		}
+	}
+}
+
+func (b *batcher) setErrorHandler(fn func(error)) {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+
+	b.onError = fn
+}
+
+func (b *batcher) sendWithRetry(batch []batchRecord) error {
+	backoff := 100 * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= b.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = b.send(batch); err == nil {
+			return nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return err
+		}
+	}
+	return err
+}
+
+// Flush sends every queued record and waits for the requests to complete
+func (b *batcher) Flush() error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return nil
+	}
+	ack := make(chan error)
+	b.flushCh <- ack
+	b.mu.RUnlock()
+	return <-ack
+}
+
+// Close sends the remaining records and stops the background goroutine
+func (b *batcher) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.ch)
+	b.mu.Unlock()
+	<-b.done
+	return nil
+}
+
+// permanentError marks a failure that retrying won't fix, such as a 4xx response
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+
+// doHTTP sends req and turns non-2xx responses into errors, 4xx ones
+// other than 429 are permanent
+func doHTTP(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package hybridlog
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// bufferedWriter keeps writes to a log file in memory, cutting the write
+// syscalls of chatty services. Unlike bufio.Writer it never splits a write,
+// so entries stay whole for rotation, signing and encryption. The buffer
+// is written out once it would overflow and every interval
+type bufferedWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	buf    []byte
+	direct bool // set once a Panic or Fatal entry is logged, writes go straight through
+	report func(error)
+
+	interval time.Duration
+	start    sync.Once // the flushes start with the first write
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newBufferedWriter(w io.Writer, size int, interval time.Duration, report func(error)) *bufferedWriter {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &bufferedWriter{
+		w:        w,
+		buf:      make([]byte, 0, size),
+		report:   report,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.start.Do(func() { go b.run() })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.buf)+len(p) > cap(b.buf) {
+		if err := b.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if b.direct || len(p) > cap(b.buf) {
+		return b.w.Write(p)
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *bufferedWriter) flushLocked() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0] // the writer reported what it lost
+	return err
+}
+
+// Flush writes out the buffer
+func (b *bufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flushLocked()
+}
+
+// unbuffer flushes the buffer and makes later writes go straight through,
+// the program is going down or the writer is closed
+func (b *bufferedWriter) unbuffer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.direct = true
+	b.flushLocked()
+}
+
+func (b *bufferedWriter) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				b.report(err)
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background flushes and flushes what is left
+func (b *bufferedWriter) Close() error {
+	b.start.Do(func() { close(b.done) }) // never written to
+	b.once.Do(func() { close(b.stop) })
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.direct = true
+	return b.flushLocked()
+}
+
+// buffered wraps w in a buffer when the options ask for one
+func (c *core) buffered(w io.Writer, opts Options) io.Writer {
+	if opts.BufferSize <= 0 {
+		return w
+	}
+	b := newBufferedWriter(w, opts.BufferSize, opts.BufferFlushInterval, c.reportError)
+	c.buffers = append(c.buffers, b)
+	return b
+}
+
+// flushBuffers writes out the buffered writes to the log files
+func (c *core) flushBuffers() error {
+	var firstErr error
+	for _, b := range c.buffers {
+		if err := b.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// unbuffer stops buffering before a Panic or Fatal entry is written
+func (c *core) unbuffer() {
+	for _, b := range c.buffers {
+		b.unbuffer()
+	}
+}
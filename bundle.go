@@ -0,0 +1,206 @@
+package hybridlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bundler packs the files of each past rotation period, the dated file and
+// its size-rotated backups, into one <name>-<date>.tar.gz next to them
+type bundler struct {
+	c    *core
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newBundler(c *core) *bundler {
+	b := &bundler{
+		c:    c,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// trigger schedules a scan, called after a rotation
+func (b *bundler) trigger() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *bundler) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		b.scan()
+		select {
+		case <-b.wake:
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background scans
+func (b *bundler) Close() {
+	b.once.Do(func() { close(b.stop) })
+	<-b.done
+}
+
+func (b *bundler) scan() {
+	current := map[string]bool{}
+	for _, f := range b.c.files() {
+		f.mu.Lock()
+		current[f.lumber.Filename] = true
+		f.mu.Unlock()
+	}
+
+	groups := map[string][]string{} // by the path of the period's file
+	for _, path := range b.c.rotatedFiles() {
+		if !strings.HasSuffix(path, ".tar.gz") {
+			key := periodFile(path)
+			groups[key] = append(groups[key], path)
+		}
+	}
+	for key, paths := range groups {
+		if current[key] {
+			continue
+		}
+		paths, pending := periodSegments(key, paths)
+		if pending {
+			continue
+		}
+		if err := bundleFiles(bundleName(key), paths, b.c.file.perm); err != nil {
+			b.c.reportError(err) // retried on the next scan
+		}
+	}
+}
+
+// periodFile returns the file of the rotation period a rotated file belongs
+// to, lumberjack's backups carry a timestamp after its name
+func periodFile(path string) string {
+	dir, base := filepath.Split(strings.TrimSuffix(path, ".gz"))
+	return filepath.Join(dir, trimBackupTime(base))
+}
+
+// bundleName turns app-2024-05-01.log into app-2024-05-01.tar.gz
+func bundleName(periodFile string) string {
+	return strings.TrimSuffix(periodFile, filepath.Ext(periodFile)) + ".tar.gz"
+}
+
+// periodSegments adds the files of the period missing from paths, backups
+// lumberjack hasn't compressed. Those written to in the last minutes are
+// being compressed, it reports them as pending to retry later
+func periodSegments(key string, paths []string) ([]string, bool) {
+	listed := map[string]bool{}
+	for _, p := range paths {
+		listed[p] = true
+	}
+	entries, err := os.ReadDir(filepath.Dir(key))
+	if err != nil {
+		return nil, true
+	}
+	for _, e := range entries {
+		path := filepath.Join(filepath.Dir(key), e.Name())
+		if e.IsDir() || listed[path] || strings.HasSuffix(path, ".tar.gz") || periodFile(path) != key {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < 10*time.Minute {
+			return nil, true
+		}
+		paths = append(paths, path)
+	}
+	return paths, false
+}
+
+// bundleFiles writes paths into the tar.gz bundle and removes them. The
+// bundle takes the modification time of the newest file for retention
+func bundleFiles(bundle string, paths []string, perm filePerm) error {
+	sort.Strings(paths)
+	tmp := bundle + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	tw := tar.NewWriter(zw)
+
+	var newest time.Time
+	for _, path := range paths {
+		var mod time.Time
+		if mod, err = addToTar(tw, path); err != nil {
+			break
+		}
+		if mod.After(newest) {
+			newest = mod
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = perm.apply(tmp, perm.fileMode)
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, newest, newest)
+	}
+	if err == nil {
+		err = os.Rename(tmp, bundle)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	for _, path := range paths {
+		if rerr := os.Remove(path); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func addToTar(tw *tar.Writer, path string) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = tw.WriteHeader(hdr); err != nil {
+		return time.Time{}, err
+	}
+	_, err = io.Copy(tw, file)
+	return info.ModTime(), err
+}
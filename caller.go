@@ -0,0 +1,56 @@
+package hybridlog
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// callerSkipPackages are never reported as the caller: logrus itself, this
+// package's wrappers and the adapters that route other logging APIs into it
+var callerSkipPackages = map[string]bool{
+	funcPackage(runtime.FuncForPC(reflect.ValueOf(logrus.New).Pointer()).Name()): true,
+	funcPackage(runtime.FuncForPC(reflect.ValueOf(Init).Pointer()).Name()):       true,
+	"log/slog": true,
+	"log":      true,
+}
+
+// funcPackage returns the package path of a fully qualified function name
+func funcPackage(fn string) string {
+	lastSlash := strings.LastIndex(fn, "/")
+	if i := strings.Index(fn[lastSlash+1:], "."); i >= 0 {
+		return fn[:lastSlash+1+i]
+	}
+	return fn
+}
+
+// callerHook replaces the caller found by logrus, which stops at the wrapper
+// functions in this package, with the first frame outside of it
+type callerHook struct {
+	skip int // extra frames to skip, for callers that wrap HybridLogger again
+}
+
+func (c *callerHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (c *callerHook) Fire(e *logrus.Entry) error {
+	if !e.HasCaller() {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	skip := c.skip
+	for f, more := frames.Next(); more; f, more = frames.Next() {
+		if callerSkipPackages[funcPackage(f.Function)] {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		e.Caller = &f
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package hybridlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The MAC of a line is HMAC-SHA256(key, MAC of the previous line | line),
+// the first line of a file chains from zeros. It's added to JSON lines as
+// an "hmac" field and appended to other lines as hmac=<hex>
+const (
+	chainJSONKey = `,"hmac":"`
+	chainTextKey = ` hmac=`
+)
+
+// hashChain signs the lines written to a file
+type hashChain struct {
+	key  []byte
+	prev []byte // MAC of the last line written
+}
+
+func newHashChain(key []byte) *hashChain {
+	return &hashChain{key: key, prev: make([]byte, sha256.Size)}
+}
+
+// reset starts the chain of a new, empty file
+func (c *hashChain) reset() {
+	c.prev = make([]byte, sha256.Size)
+}
+
+// sign adds the MAC to each line of p, returning the signed data and the
+// MAC of its last line to continue from once written
+func (c *hashChain) sign(p []byte) ([]byte, []byte) {
+	out := make([]byte, 0, len(p)+80)
+	prev := c.prev
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i]
+		}
+		p = p[min(len(line)+1, len(p)):]
+
+		prev = chainMAC(c.key, prev, line)
+		sum := hex.EncodeToString(prev)
+		if isJSONObject(line) {
+			out = append(out, line[:len(line)-1]...)
+			out = append(out, chainJSONKey...)
+			out = append(out, sum...)
+			out = append(out, `"}`...)
+		} else {
+			out = append(out, line...)
+			out = append(out, chainTextKey...)
+			out = append(out, sum...)
+		}
+		out = append(out, '\n')
+	}
+	return out, prev
+}
+
+// resume continues the chain from the last line of an existing file
+func (c *hashChain) resume(name string, enc *encrypter) error {
+	c.reset()
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if enc != nil {
+		r = NewDecryptReader(file, enc.aeads.keys)
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to resume hash chain: %v", err)
+		}
+		if _, mac, ok := splitChainMAC(bytes.TrimSuffix(line, []byte("\n"))); ok {
+			c.prev = mac
+		}
+	}
+}
+
+func chainMAC(key, prev, line []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(prev)
+	m.Write(line)
+	return m.Sum(nil)
+}
+
+func isJSONObject(line []byte) bool {
+	return len(line) > 2 && line[0] == '{' && line[len(line)-1] == '}'
+}
+
+// splitChainMAC returns a signed line without its MAC, and the MAC
+func splitChainMAC(line []byte) ([]byte, []byte, bool) {
+	const hexLen = 2 * sha256.Size
+	if isJSONObject(line) {
+		end := len(line) - 2 // before "}
+		start := end - hexLen
+		if start-len(chainJSONKey) < 1 || string(line[start-len(chainJSONKey):start]) != chainJSONKey || line[end] != '"' {
+			return nil, nil, false
+		}
+		mac, err := hex.DecodeString(string(line[start:end]))
+		if err != nil {
+			return nil, nil, false
+		}
+		body := append(line[:start-len(chainJSONKey):start-len(chainJSONKey)], '}')
+		return body, mac, true
+	}
+	start := len(line) - hexLen
+	if start-len(chainTextKey) < 0 || string(line[start-len(chainTextKey):start]) != chainTextKey {
+		return nil, nil, false
+	}
+	mac, err := hex.DecodeString(string(line[start:]))
+	if err != nil {
+		return nil, nil, false
+	}
+	return line[:start-len(chainTextKey)], mac, true
+}
+
+// ChainError describes the first line of a log file that fails Verify
+type ChainError struct {
+	Line   int // 1-based
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks the hash chain of a log file written with WithHashChain,
+// gzip'd backups included. Modified, inserted, removed or reordered lines
+// fail it, as do lines cut from the start or a partly written last line.
+// Whole lines cut from the end can only be told by comparing the MAC of
+// the last line with one recorded elsewhere
+func Verify(path string, key []byte) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		if r, err = gzip.NewReader(file); err != nil {
+			return err
+		}
+	}
+	return VerifyReader(r, key)
+}
+
+// VerifyReader checks the hash chain of the lines read from r, e.g. from
+// NewDecryptReader for encrypted files. See Verify
+func VerifyReader(r io.Reader, key []byte) error {
+	prev := make([]byte, sha256.Size)
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF {
+			return &ChainError{Line: n, Reason: "incomplete line"}
+		}
+
+		body, mac, ok := splitChainMAC(line[:len(line)-1])
+		if !ok {
+			return &ChainError{Line: n, Reason: "no hmac"}
+		}
+		prev = chainMAC(key, prev, body)
+		if !hmac.Equal(prev, mac) {
+			return &ChainError{Line: n, Reason: "hmac mismatch, the line or one before it was changed"}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const cloudLoggingScope = "https://www.googleapis.com/auth/logging.write"
+
+// CloudLoggingOptions configures the Google Cloud Logging output
+type CloudLoggingOptions struct {
+	ProjectID       string            // defaults to the credentials' project or GOOGLE_CLOUD_PROJECT
+	LogID           string            // log name within the project, defaults to "app"
+	ResourceType    string            // monitored resource type, defaults to "global"
+	ResourceLabels  map[string]string // monitored resource labels, e.g. project_id or zone
+	Labels          map[string]string // labels added to every entry
+	CredentialsFile string            // service account key, defaults to GOOGLE_APPLICATION_CREDENTIALS then the metadata server
+	TokenSource     GCPTokenSource    // custom access tokens, overrides CredentialsFile
+	Endpoint        string            // defaults to https://logging.googleapis.com
+	Formatter       logrus.Formatter  // payload formatter, JSON objects become jsonPayload, defaults to the file formatter
+	Batch           BatchOptions      // MaxEntries is capped at 1000
+	Client          *http.Client      // defaults to a client with a 10s timeout
+}
+
+var cloudLoggingSeverity = map[logrus.Level]string{
+	logrus.PanicLevel: "ALERT",
+	logrus.FatalLevel: "CRITICAL",
+	logrus.ErrorLevel: "ERROR",
+	logrus.WarnLevel:  "WARNING",
+	logrus.InfoLevel:  "INFO",
+	logrus.DebugLevel: "DEBUG",
+	logrus.TraceLevel: "DEBUG",
+}
+
+type cloudLoggingResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type cloudLoggingEntry struct {
+	Severity    string          `json:"severity"`
+	Timestamp   string          `json:"timestamp"`
+	JSONPayload json.RawMessage `json:"jsonPayload,omitempty"`
+	TextPayload string          `json:"textPayload,omitempty"`
+}
+
+// newCloudLoggingWriter returns a batching writer calling entries:write. It
+// returns errNoGCPCredentials when no credentials can be found
+func newCloudLoggingWriter(opts CloudLoggingOptions) (*batcher, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	tokens := opts.TokenSource
+	if tokens == nil {
+		var project string
+		var err error
+		if tokens, project, err = gcpCredentials(opts.CredentialsFile, cloudLoggingScope, opts.Client); err != nil {
+			return nil, err
+		}
+		if opts.ProjectID == "" {
+			opts.ProjectID = project
+		}
+	}
+	if opts.ProjectID == "" {
+		opts.ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if opts.ProjectID == "" {
+		return nil, errors.New("cloud logging: no project ID")
+	}
+	if opts.LogID == "" {
+		opts.LogID = "app"
+	}
+	if opts.ResourceType == "" {
+		opts.ResourceType = "global"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://logging.googleapis.com"
+	}
+	if opts.Batch.MaxEntries > 1000 {
+		opts.Batch.MaxEntries = 1000
+	}
+	endpoint := strings.TrimRight(opts.Endpoint, "/") + "/v2/entries:write"
+	logName := "projects/" + opts.ProjectID + "/logs/" + url.PathEscape(opts.LogID)
+
+	return newBatcher(opts.Batch, func(batch []batchRecord) error {
+		entries := make([]cloudLoggingEntry, 0, len(batch))
+		for _, rec := range batch {
+			e := cloudLoggingEntry{
+				Severity:  cloudLoggingSeverity[rec.level],
+				Timestamp: rec.time.UTC().Format(time.RFC3339Nano),
+			}
+			if len(rec.line) > 0 && rec.line[0] == '{' && json.Valid(rec.line) {
+				e.JSONPayload = rec.line
+			} else {
+				e.TextPayload = string(rec.line)
+			}
+			entries = append(entries, e)
+		}
+		payload := map[string]interface{}{
+			"logName":  logName,
+			"resource": cloudLoggingResource{Type: opts.ResourceType, Labels: opts.ResourceLabels},
+			"entries":  entries,
+		}
+		if len(opts.Labels) > 0 {
+			payload["labels"] = opts.Labels
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return permanentError{err}
+		}
+
+		token, err := tokens()
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return doHTTP(opts.Client, req)
+	}), nil
+}
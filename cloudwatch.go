@@ -0,0 +1,262 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CloudWatch Logs PutLogEvents limits
+const (
+	cwMaxEvents     = 10000
+	cwMaxBatchBytes = 1048576
+	cwEventOverhead = 26
+	cwMaxEventBytes = 262144 - cwEventOverhead
+	cwMaxSpan       = 24 * time.Hour
+)
+
+// CloudWatchOptions configures the AWS CloudWatch Logs output
+type CloudWatchOptions struct {
+	Region         string                 // AWS region, defaults to AWS_REGION or AWS_DEFAULT_REGION
+	LogGroup       string                 // log group name
+	LogStream      string                 // log stream name, defaults to the host name
+	CreateLogGroup bool                   // create the log group as well as the stream when missing
+	Credentials    AWSCredentialsProvider // defaults to DefaultAWSCredentials
+	Endpoint       string                 // defaults to https://logs.<region>.amazonaws.com
+	Formatter      logrus.Formatter       // message formatter, defaults to the file formatter
+	Batch          BatchOptions           // MaxEntries is capped at 10000 and MaxInFlight at 1
+	Client         *http.Client           // defaults to a client with a 10s timeout
+}
+
+type cwEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+type cwError struct {
+	Type                  string `json:"__type"`
+	Message               string `json:"message"`
+	ExpectedSequenceToken string `json:"expectedSequenceToken"`
+}
+
+func (e *cwError) Error() string {
+	return "cloudwatch logs: " + e.Type + ": " + e.Message
+}
+
+// cloudWatchWriter sends batches with PutLogEvents, creating the stream on
+// first use and tracking the sequence token
+type cloudWatchWriter struct {
+	*batcher
+	opts CloudWatchOptions
+
+	mu       sync.Mutex
+	token    string // sequence token for the next call, ignored by newer accounts
+	sent     *batchRecord
+	sentUpTo int // records of the batch starting at sent already accepted, so retries skip them
+}
+
+func newCloudWatchWriter(opts CloudWatchOptions) *cloudWatchWriter {
+	if opts.Region == "" {
+		opts.Region = os.Getenv("AWS_REGION")
+	}
+	if opts.Region == "" {
+		opts.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if opts.LogStream == "" {
+		opts.LogStream, _ = os.Hostname()
+	}
+	if opts.Credentials == nil {
+		opts.Credentials = DefaultAWSCredentials()
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://logs." + opts.Region + ".amazonaws.com"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Batch.MaxEntries > cwMaxEvents {
+		opts.Batch.MaxEntries = cwMaxEvents
+	}
+	opts.Batch.MaxInFlight = 1 // sequence tokens need calls in order
+
+	w := &cloudWatchWriter{opts: opts}
+	w.batcher = newBatcher(opts.Batch, w.send)
+	return w
+}
+
+// send splits the batch into PutLogEvents calls within the API limits
+func (w *cloudWatchWriter) send(batch []batchRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sent != &batch[0] {
+		w.sent, w.sentUpTo = &batch[0], 0
+	}
+	recs := batch[w.sentUpTo:]
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].time.Before(recs[j].time) })
+
+	for len(recs) > 0 {
+		var events []cwEvent
+		size := 0
+		first := recs[0].time
+		for _, rec := range recs {
+			msg := truncateUTF8(rec.line, cwMaxEventBytes)
+			if len(events) == cwMaxEvents || size+len(msg)+cwEventOverhead > cwMaxBatchBytes || rec.time.Sub(first) > cwMaxSpan {
+				break
+			}
+			size += len(msg) + cwEventOverhead
+			events = append(events, cwEvent{Timestamp: rec.time.UnixMilli(), Message: string(msg)})
+		}
+		if err := w.putLogEvents(events); err != nil {
+			return err
+		}
+		w.sentUpTo += len(events)
+		recs = recs[len(events):]
+	}
+	return nil
+}
+
+func (w *cloudWatchWriter) putLogEvents(events []cwEvent) error {
+	created := false
+	for {
+		req := map[string]interface{}{
+			"logGroupName":  w.opts.LogGroup,
+			"logStreamName": w.opts.LogStream,
+			"logEvents":     events,
+		}
+		if w.token != "" {
+			req["sequenceToken"] = w.token
+		}
+		var resp struct {
+			NextSequenceToken string `json:"nextSequenceToken"`
+		}
+		err := w.call("PutLogEvents", req, &resp)
+
+		var cwErr *cwError
+		if !errors.As(err, &cwErr) {
+			if err == nil {
+				w.token = resp.NextSequenceToken
+			}
+			return err
+		}
+		switch cwErr.Type {
+		case "InvalidSequenceTokenException":
+			w.token = cwErr.ExpectedSequenceToken
+		case "DataAlreadyAcceptedException":
+			w.token = cwErr.ExpectedSequenceToken
+			return nil
+		case "ResourceNotFoundException":
+			if created {
+				return permanentError{err}
+			}
+			if err := w.createStream(); err != nil {
+				return err
+			}
+			created, w.token = true, ""
+		default:
+			return err
+		}
+	}
+}
+
+func (w *cloudWatchWriter) createStream() error {
+	if w.opts.CreateLogGroup {
+		err := w.call("CreateLogGroup", map[string]string{"logGroupName": w.opts.LogGroup}, nil)
+		if err != nil && !isCWError(err, "ResourceAlreadyExistsException") {
+			return err
+		}
+	}
+	err := w.call("CreateLogStream", map[string]string{"logGroupName": w.opts.LogGroup, "logStreamName": w.opts.LogStream}, nil)
+	if err != nil && !isCWError(err, "ResourceAlreadyExistsException") {
+		return err
+	}
+	return nil
+}
+
+// call invokes a CloudWatch Logs JSON API action, errors reported by the
+// service are returned as *cwError
+func (w *cloudWatchWriter) call(action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return permanentError{err}
+	}
+	creds, err := w.opts.Credentials()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(w.opts.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	signAWSv4(req, sha256Hex(body), creds, w.opts.Region, "logs", time.Now())
+
+	resp, err := w.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		if out == nil || len(data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	}
+
+	cwErr := &cwError{}
+	if json.Unmarshal(data, cwErr) != nil || cwErr.Type == "" {
+		err = fmt.Errorf("cloudwatch logs %s: %s: %s", action, resp.Status, bytes.TrimSpace(data))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError{err}
+		}
+		return err
+	}
+	if i := strings.LastIndexByte(cwErr.Type, '#'); i >= 0 {
+		cwErr.Type = cwErr.Type[i+1:]
+	}
+	switch cwErr.Type {
+	case "InvalidSequenceTokenException", "DataAlreadyAcceptedException", "ResourceNotFoundException",
+		"ResourceAlreadyExistsException", "ThrottlingException", "ServiceUnavailableException":
+		return cwErr
+	}
+	if resp.StatusCode < 500 {
+		return permanentError{cwErr}
+	}
+	return cwErr
+}
+
+func isCWError(err error, typ string) bool {
+	var cwErr *cwError
+	return errors.As(err, &cwErr) && cwErr.Type == typ
+}
+
+// truncateUTF8 cuts b to at most n bytes without splitting a rune
+func truncateUTF8(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+	b = b[:n]
+	for i := 0; i < utf8.UTFMax-1 && len(b) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(b); r != utf8.RuneError || size > 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+// Command hybridlog-decrypt prints log files written with
+// hybridlog.WithEncryption in plain text. It reads gzip'd backups and
+// tar.gz bundles too, and standard input without file arguments:
+//
+//	hybridlog-decrypt -key-file /etc/app/log.key logs/app-2024-05-01.log
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	hybridlog "github.com/git4rakesh/hybrid_log"
+)
+
+// fileKey is the key read from the key file, registered under id
+type fileKey struct {
+	id  string
+	key []byte
+}
+
+func (k fileKey) CurrentKey() (string, []byte, error) { return k.id, k.key, nil }
+
+func (k fileKey) Key(id string) ([]byte, error) {
+	if id != k.id {
+		return nil, fmt.Errorf("key id %q not given, use -key-id", id)
+	}
+	return k.key, nil
+}
+
+func main() {
+	keyFile := flag.String("key-file", "", "file holding the AES key, raw or hex encoded")
+	keyID := flag.String("key-id", "", "id the key was used under")
+	flag.Parse()
+
+	if *keyFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: hybridlog-decrypt -key-file file [-key-id id] [log file ...]")
+		os.Exit(2)
+	}
+	key, err := readKey(*keyFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	keys := fileKey{*keyID, key}
+
+	if flag.NArg() == 0 {
+		err = decrypt(os.Stdout, os.Stdin, keys)
+	}
+	for _, name := range flag.Args() {
+		if err = decryptFile(os.Stdout, name, keys); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func readKey(name string) ([]byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if text := strings.TrimSpace(string(b)); len(text) == 32 || len(text) == 48 || len(text) == 64 {
+		if key, err := hex.DecodeString(text); err == nil {
+			return key, nil
+		}
+	}
+	return b, nil
+}
+
+func decryptFile(w io.Writer, name string, keys hybridlog.KeyProvider) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(name, ".tar.gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		tr := tar.NewReader(zr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			var r io.Reader = tr
+			if strings.HasSuffix(hdr.Name, ".gz") {
+				if r, err = gzip.NewReader(tr); err != nil {
+					return fmt.Errorf("%s: %s: %v", name, hdr.Name, err)
+				}
+			}
+			if err := decrypt(w, r, keys); err != nil {
+				return fmt.Errorf("%s: %s: %v", name, hdr.Name, err)
+			}
+		}
+	}
+
+	var r io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		if r, err = gzip.NewReader(file); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if err := decrypt(w, r, keys); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+func decrypt(w io.Writer, r io.Reader, keys hybridlog.KeyProvider) error {
+	_, err := io.Copy(w, hybridlog.NewDecryptReader(r, keys))
+	return err
+}
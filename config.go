@@ -0,0 +1,397 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config is the file form of Options, see InitFromFile. Levels, formats
+// and durations are written as names and strings such as "info", "logfmt"
+// and "30s". Keys left out keep the values of DefaultOptions, the limits
+// are pointers so that an explicit 0, for no limit, tells from a missing key
+type Config struct {
+	LogDir         string                 `json:"log_dir"`
+	FileName       string                 `json:"file_name"`
+	MaxSizeMB      *int                   `json:"max_size_mb"`
+	MaxBackups     *int                   `json:"max_backups"`
+	MaxAgeDays     *int                   `json:"max_age_days"`
+	MaxTotalSizeMB *int                   `json:"max_total_size_mb"`
+	MaxLines       int                    `json:"max_lines"`
+	Compress       bool                   `json:"compress"`
+	Level          string                 `json:"level"`
+	Rotation       string                 `json:"rotation"`  // "daily", "hourly", "weekly", "monthly" or a whole number of hours such as "6h"
+	RotateAt       string                 `json:"rotate_at"` // "HH:MM" periods start at, e.g. "02:00"
+	DatePattern    string                 `json:"date_pattern"`
+	Format         string                 `json:"format"` // "json", "fastjson", "text", "logfmt" or "ecs"
+	Console        bool                   `json:"console"`
+	ReportCaller   bool                   `json:"report_caller"`
+	GlobalFields   map[string]interface{} `json:"global_fields"`
+	NameTemplate   string                 `json:"file_name_template"` // see Options.FileNameTemplate
+	TimeFormat     string                 `json:"timestamp_format"`   // "rfc3339", "rfc3339nano", "epoch_millis" or a time layout
+	FieldKeys      map[string]string      `json:"field_keys"`         // e.g. {"time": "@timestamp", "level": "severity", "msg": "message"}
+
+	Async     *AsyncConfig            `json:"async"`
+	ErrorFile *FileConfig             `json:"error_file"`
+	Redact    *RedactConfig           `json:"redact"`
+	Syslog    *SyslogConfig           `json:"syslog"`
+	Network   *NetworkConfig          `json:"network"`
+	Loki      *LokiConfig             `json:"loki"`
+	Webhook   *WebhookConfig          `json:"webhook"`
+	Sampling  map[string]SamplingRule `json:"sampling"` // by level name, e.g. "debug": {"every": 10}
+
+	Sinks      map[string]map[string]interface{} `json:"sinks"`       // sinks added with RegisterSink, by name with their config
+	SinkLevels map[string]string                 `json:"sink_levels"` // level name per destination, e.g. "console": "info"
+}
+
+// AsyncConfig is the file form of the Async options
+type AsyncConfig struct {
+	BufferSize    int    `json:"buffer_size"`
+	FlushInterval string `json:"flush_interval"`
+	DropWhenFull  bool   `json:"drop_when_full"`
+}
+
+// FileConfig is the file form of FileOptions
+type FileConfig struct {
+	FileName   string `json:"file_name"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+	MaxAgeDays int    `json:"max_age_days"`
+	Compress   bool   `json:"compress"`
+}
+
+// RedactConfig is the file form of RedactOptions, patterns are regular expressions
+type RedactConfig struct {
+	Fields   []string `json:"fields"`
+	Patterns []string `json:"patterns"`
+	Mask     string   `json:"mask"`
+}
+
+// SyslogConfig is the file form of SyslogOptions
+type SyslogConfig struct {
+	Network  string `json:"network"`
+	Address  string `json:"address"`
+	Facility *int   `json:"facility"` // unset means user, 0 is kern
+	Tag      string `json:"tag"`
+}
+
+// NetworkConfig is the file form of NetworkOptions
+type NetworkConfig struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+}
+
+// LokiConfig is the file form of LokiOptions
+type LokiConfig struct {
+	URL      string            `json:"url"`
+	Labels   map[string]string `json:"labels"`
+	TenantID string            `json:"tenant_id"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+}
+
+// WebhookConfig is the file form of WebhookOptions
+type WebhookConfig struct {
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers"`
+	RatePerMinute int               `json:"rate_per_minute"`
+}
+
+// InitFromFile initializes the logger from a JSON config file, see Config
+func InitFromFile(path string) (*HybridLogger, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := cfg.Options()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return InitWithOptions(opts)
+}
+
+// LoadConfig reads a JSON config file. Unknown keys and values of the wrong
+// type are rejected with their line and column. YAML and TOML aren't
+// supported, they would need a parser this package doesn't depend on
+func LoadConfig(path string) (Config, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return Config{}, fmt.Errorf("%s: only JSON config files are supported", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%s%s", path, describeJSONError(data, dec, err))
+	}
+	return cfg, nil
+}
+
+// describeJSONError points at the line and column of a decoding error
+func describeJSONError(data []byte, dec *json.Decoder, err error) string {
+	offset := dec.InputOffset()
+	msg := err.Error()
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+		msg = fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value)
+	}
+	msg = strings.TrimPrefix(msg, "json: ")
+	if name, ok := strings.CutPrefix(msg, "unknown field "); ok {
+		if i := bytes.Index(data, []byte(name)); i >= 0 {
+			offset = int64(i) // decoding fails at the end of the object
+		}
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	col := offset - int64(bytes.LastIndexByte(data[:offset], '\n'))
+	return fmt.Sprintf(":%d:%d: %s", line, col, msg)
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "true or false"
+	case kind == "map", kind == "struct":
+		return "an object"
+	case kind == "slice":
+		return "a list"
+	}
+	return "a " + kind
+}
+
+// Options converts the config, checking the names and strings it contains.
+// Settings the file leaves out keep their DefaultOptions values
+func (c Config) Options() (Options, error) {
+	o := DefaultOptions()
+	if c.LogDir != "" {
+		o.LogDir = c.LogDir
+	}
+	if c.FileName != "" {
+		o.FileName = c.FileName
+	}
+	limits := []struct {
+		dst *int
+		src *int
+	}{
+		{&o.MaxSizeMB, c.MaxSizeMB},
+		{&o.MaxBackups, c.MaxBackups},
+		{&o.MaxAgeDays, c.MaxAgeDays},
+		{&o.MaxTotalSizeMB, c.MaxTotalSizeMB},
+	}
+	for _, l := range limits {
+		if l.src != nil {
+			*l.dst = *l.src
+		}
+	}
+	o.MaxLines = c.MaxLines
+	o.Compress = c.Compress
+	o.LevelName = c.Level
+	o.RotateAt = c.RotateAt
+	o.DatePattern = c.DatePattern
+	o.ConsoleOutput = c.Console
+	o.ReportCaller = c.ReportCaller
+	o.GlobalFields = c.GlobalFields
+	o.Sinks = c.Sinks
+	o.FileNameTemplate = c.NameTemplate
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			return o, fmt.Errorf("level: %v", err)
+		}
+	}
+
+	var err error
+	if o.RotationInterval, err = parseRotation(c.Rotation); err != nil {
+		return o, fmt.Errorf("rotation: %v", err)
+	}
+	if c.RotateAt != "" {
+		if _, err = parseRotateAt(c.RotateAt); err != nil {
+			return o, fmt.Errorf("rotate_at: %v", err)
+		}
+	}
+	if o.Format, err = parseFormatName(c.Format); err != nil {
+		return o, fmt.Errorf("format: %v", err)
+	}
+	o.TimestampFormat = parseTimestampFormat(c.TimeFormat)
+	if o.FieldKeys, err = parseFieldKeys(c.FieldKeys); err != nil {
+		return o, fmt.Errorf("field_keys: %v", err)
+	}
+
+	if c.Async != nil {
+		o.Async = true
+		o.AsyncBufferSize = c.Async.BufferSize
+		o.AsyncDropWhenFull = c.Async.DropWhenFull
+		if c.Async.FlushInterval != "" {
+			if o.AsyncFlushInterval, err = time.ParseDuration(c.Async.FlushInterval); err != nil {
+				return o, fmt.Errorf("async.flush_interval: %v", err)
+			}
+		}
+	}
+	if c.ErrorFile != nil {
+		o.ErrorFile = &FileOptions{
+			FileName:   c.ErrorFile.FileName,
+			MaxSizeMB:  c.ErrorFile.MaxSizeMB,
+			MaxBackups: c.ErrorFile.MaxBackups,
+			MaxAgeDays: c.ErrorFile.MaxAgeDays,
+			Compress:   c.ErrorFile.Compress,
+		}
+	}
+	if c.Redact != nil {
+		o.Redact = &RedactOptions{Fields: c.Redact.Fields, Mask: c.Redact.Mask}
+		for i, p := range c.Redact.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return o, fmt.Errorf("redact.patterns[%d]: %v", i, err)
+			}
+			o.Redact.Patterns = append(o.Redact.Patterns, re)
+		}
+	}
+	if c.Syslog != nil {
+		o.Syslog = &SyslogOptions{Network: c.Syslog.Network, Address: c.Syslog.Address, Tag: c.Syslog.Tag}
+		if f := c.Syslog.Facility; f != nil {
+			if o.Syslog.Facility = *f; *f == 0 {
+				o.Syslog.Facility = FacilityKern
+			}
+		}
+	}
+	if c.Network != nil {
+		if c.Network.Address == "" {
+			return o, fmt.Errorf("network.address is required")
+		}
+		o.Network = &NetworkOptions{Network: c.Network.Network, Address: c.Network.Address}
+	}
+	if c.Loki != nil {
+		if c.Loki.URL == "" {
+			return o, fmt.Errorf("loki.url is required")
+		}
+		o.Loki = &LokiOptions{URL: c.Loki.URL, Labels: c.Loki.Labels, TenantID: c.Loki.TenantID, Username: c.Loki.Username, Password: c.Loki.Password}
+	}
+	if c.Webhook != nil {
+		if c.Webhook.URL == "" {
+			return o, fmt.Errorf("webhook.url is required")
+		}
+		o.Webhook = &WebhookOptions{URL: c.Webhook.URL, Headers: c.Webhook.Headers, RatePerMinute: c.Webhook.RatePerMinute}
+	}
+	for name, level := range c.SinkLevels {
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return o, fmt.Errorf("sink_levels.%s: %v", name, err)
+		}
+		if o.SinkLevels == nil {
+			o.SinkLevels = map[string]logrus.Level{}
+		}
+		o.SinkLevels[name] = logrus.Level(lvl)
+	}
+	for name, rule := range c.Sampling {
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			return o, fmt.Errorf("sampling: %v", err)
+		}
+		if o.Sampling == nil {
+			o.Sampling = map[logrus.Level]SamplingRule{}
+		}
+		o.Sampling[logrus.Level(lvl)] = rule
+	}
+	return o, nil
+}
+
+// parseRotation converts "daily", "hourly", "weekly", "monthly" or a
+// duration to a rotation interval
+func parseRotation(s string) (time.Duration, error) {
+	switch strings.ToLower(s) {
+	case "", "daily":
+		return Daily, nil
+	case "hourly":
+		return Hourly, nil
+	case "weekly":
+		return Weekly, nil
+	case "monthly":
+		return Monthly, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("want daily, hourly, weekly, monthly or a number of hours, got %q", s)
+	}
+	if rotationInterval(d) != d {
+		return 0, fmt.Errorf("%s doesn't divide a day into whole hours", s)
+	}
+	return d, nil
+}
+
+// parseFormatName converts a format name to a Format
+func parseFormatName(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "json":
+		return FormatJSON, nil
+	case "text":
+		return FormatText, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	case "ecs":
+		return FormatECS, nil
+	case "fastjson":
+		return FormatFastJSON, nil
+	}
+	return 0, fmt.Errorf("unknown log format %q, want json, fastjson, text, logfmt or ecs", s)
+}
+
+// parseTimestampFormat converts the names of common timestamp formats to
+// layouts, anything else is a layout already
+func parseTimestampFormat(s string) string {
+	switch strings.ToLower(s) {
+	case "rfc3339":
+		return time.RFC3339
+	case "rfc3339nano":
+		return time.RFC3339Nano
+	case EpochMillis:
+		return EpochMillis
+	}
+	return s
+}
+
+// parseFieldKeys converts renamed keys to a logrus.FieldMap
+func parseFieldKeys(m map[string]string) (logrus.FieldMap, error) {
+	if m == nil {
+		return nil, nil
+	}
+	keys := logrus.FieldMap{}
+	for k, v := range m {
+		switch k {
+		case logrus.FieldKeyTime:
+			keys[logrus.FieldKeyTime] = v
+		case logrus.FieldKeyLevel:
+			keys[logrus.FieldKeyLevel] = v
+		case logrus.FieldKeyMsg:
+			keys[logrus.FieldKeyMsg] = v
+		case logrus.FieldKeyFunc:
+			keys[logrus.FieldKeyFunc] = v
+		case logrus.FieldKeyFile:
+			keys[logrus.FieldKeyFile] = v
+		default:
+			return nil, fmt.Errorf("unknown key %q, want time, level, msg, func or file", k)
+		}
+	}
+	return keys, nil
+}
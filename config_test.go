@@ -0,0 +1,67 @@
+package hybridlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigLimits(t *testing.T) {
+	def := DefaultOptions()
+	tests := []struct {
+		name    string
+		json    string
+		backups int
+		age     int
+	}{
+		{"omitted", `{}`, def.MaxBackups, def.MaxAgeDays},
+		{"set", `{"max_backups": 3, "max_age_days": 5}`, 3, 5},
+		{"unlimited", `{"max_backups": 0, "max_age_days": 0}`, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := cfg.Options()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.MaxBackups != tt.backups || o.MaxAgeDays != tt.age {
+				t.Fatalf("got backups %d, age %d, want %d, %d", o.MaxBackups, o.MaxAgeDays, tt.backups, tt.age)
+			}
+		})
+	}
+}
+
+func TestApplyConfigRetention(t *testing.T) {
+	h, err := New(WithLogDir(t.TempDir()), WithMaxBackups(4), WithMaxAge(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	zero := 0
+	steps := []struct {
+		name string
+		cfg  Config
+		want [3]int
+	}{
+		{"no limits", Config{Level: "debug"}, [3]int{4, 9, 0}},
+		{"backups", Config{MaxBackups: &zero}, [3]int{0, 9, 0}},
+		{"backups left out", Config{}, [3]int{4, 9, 0}},
+	}
+	for _, s := range steps {
+		if err := h.ApplyConfig(s.cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.retentionLimits(); got != s.want {
+			t.Fatalf("%s: got %v, want %v", s.name, got, s.want)
+		}
+	}
+}
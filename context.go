@@ -0,0 +1,91 @@
+package hybridlog
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ContextExtractor returns fields to add to an entry from values stored in ctx
+type ContextExtractor func(ctx context.Context) logrus.Fields
+
+type ctxFieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying fields, they are added to
+// every entry logged with that context
+func ContextWithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := logrus.Fields{}
+	if prev, ok := ctx.Value(ctxFieldsKey{}).(logrus.Fields); ok {
+		for k, v := range prev {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, ctxFieldsKey{}, merged)
+}
+
+// FieldFromContext returns an extractor that adds ctx.Value(key) as field
+// name when it is set, e.g. FieldFromContext("request_id", requestIDKey{})
+func FieldFromContext(name string, key interface{}) ContextExtractor {
+	return func(ctx context.Context) logrus.Fields {
+		if v := ctx.Value(key); v != nil {
+			return logrus.Fields{name: v}
+		}
+		return nil
+	}
+}
+
+func fieldsFromContext(ctx context.Context) logrus.Fields {
+	fields, _ := ctx.Value(ctxFieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// AddContextExtractor registers an extractor run for every entry that has a context
+func (h *HybridLogger) AddContextExtractor(fn ContextExtractor) {
+	h.ctxMu.Lock()
+	defer h.ctxMu.Unlock()
+
+	h.extractors = append(h.extractors, fn)
+}
+
+// WithContext creates an entry carrying ctx, fields from the registered
+// extractors are added when it is logged
+func (h *HybridLogger) WithContext(ctx context.Context) *Entry {
+	return h.newEntry(h.Logger.WithContext(ctx))
+}
+
+// WithContext sets the context of the entry
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	return e.h.newEntry(e.Entry.WithContext(ctx))
+}
+
+// contextHook adds context fields to entries, explicit fields take precedence
+type contextHook struct {
+	c *core
+}
+
+func (c *contextHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (c *contextHook) Fire(e *logrus.Entry) error {
+	if e.Context == nil {
+		return nil
+	}
+	add := func(fields logrus.Fields) {
+		for k, v := range fields {
+			if _, ok := e.Data[k]; !ok {
+				e.Data[k] = v
+			}
+		}
+	}
+	add(fieldsFromContext(e.Context))
+
+	c.c.ctxMu.RLock()
+	extractors := c.c.extractors
+	c.c.ctxMu.RUnlock()
+	for _, fn := range extractors {
+		add(fn(e.Context))
+	}
+	return nil
+}
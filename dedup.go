@@ -0,0 +1,103 @@
+package hybridlog
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dedupRepeat marks the context of repeat lines so they are not collapsed themselves
+type dedupRepeat struct{}
+
+// dedupHook collapses consecutive identical entries like syslog's "last
+// message repeated N times": the first is written, the repeats are counted
+// and reported as a copy of the entry with a repeat_count field once a
+// different entry arrives or window has passed
+type dedupHook struct {
+	l      *logrus.Logger
+	window time.Duration
+
+	mu      sync.Mutex
+	last    *logrus.Entry // the last entry written
+	repeats int
+	lastAt  time.Time
+	timer   *time.Timer
+}
+
+func newDedupHook(l *logrus.Logger, window time.Duration) *dedupHook {
+	return &dedupHook{l: l, window: window}
+}
+
+// Levels leaves out Fatal and Panic, which end the program anyway
+func (d *dedupHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel, logrus.DebugLevel, logrus.TraceLevel}
+}
+
+func (d *dedupHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) || e.Context != nil && e.Context.Value(dedupRepeat{}) != nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	if d.last != nil && sameEntry(d.last, e) {
+		d.repeats++
+		d.lastAt = e.Time
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window, d.Flush)
+		}
+		d.mu.Unlock()
+		dropEntry(e)
+		return nil
+	}
+	prev, repeats, at := d.take()
+	d.last = &logrus.Entry{Level: e.Level, Message: e.Message, Data: copyFields(e.Data)}
+	d.mu.Unlock()
+
+	d.report(prev, repeats, at) // before e, which is written once the hooks return
+	return nil
+}
+
+// take returns the pending repeats and resets them, d.mu must be held
+func (d *dedupHook) take() (*logrus.Entry, int, time.Time) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	repeats := d.repeats
+	d.repeats = 0
+	return d.last, repeats, d.lastAt
+}
+
+// Flush writes the pending repeat line, if any
+func (d *dedupHook) Flush() {
+	d.mu.Lock()
+	prev, repeats, at := d.take()
+	d.mu.Unlock()
+
+	d.report(prev, repeats, at)
+}
+
+func (d *dedupHook) report(prev *logrus.Entry, repeats int, at time.Time) {
+	if repeats == 0 {
+		return
+	}
+	fields := copyFields(prev.Data)
+	fields["repeat_count"] = repeats
+	ctx := context.WithValue(context.Background(), dedupRepeat{}, true)
+	d.l.WithContext(ctx).WithTime(at).WithFields(fields).Log(prev.Level, prev.Message)
+}
+
+func sameEntry(a, b *logrus.Entry) bool {
+	return a.Level == b.Level && a.Message == b.Message && reflect.DeepEqual(a.Data, b.Data)
+}
+
+func copyFields(fields logrus.Fields) logrus.Fields {
+	copied := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return copied
+}
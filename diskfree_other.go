@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package hybridlog
+
+import "errors"
+
+func diskUsage(dir string) (free, total uint64, err error) {
+	return 0, 0, errors.New("free disk space is not available on this platform")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package hybridlog
+
+import "golang.org/x/sys/windows"
+
+// diskUsage returns the bytes available to the caller on the volume holding
+// dir and its size
+func diskUsage(dir string) (free, total uint64, err error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := windows.GetDiskFreeSpaceEx(p, &free, &total, nil); err != nil {
+		return 0, 0, err
+	}
+	return free, total, nil
+}
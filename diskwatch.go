@@ -0,0 +1,106 @@
+package hybridlog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskWatchOptions configures the disk space watchdog
+type DiskWatchOptions struct {
+	MinFreeMB  int64                               // purge rotated files while less than this is free in the log dir
+	Interval   time.Duration                       // how often free space is checked, defaults to 30s
+	OnLowSpace func(freeMB int64, purged []string) // called after each purge, may be nil
+}
+
+// diskWatch checks free space in the log dir and deletes the oldest rotated
+// files when it runs low, the files in use are never touched
+type diskWatch struct {
+	opts DiskWatchOptions
+	c    *core
+	dir  string
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newDiskWatch(c *core, dir string, opts DiskWatchOptions) *diskWatch {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	d := &diskWatch{opts: opts, c: c, dir: dir, stop: make(chan struct{}), done: make(chan struct{})}
+	go d.run()
+	return d
+}
+
+func (d *diskWatch) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.check()
+		select {
+		case <-ticker.C:
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *diskWatch) check() {
+	min := uint64(d.opts.MinFreeMB) << 20
+	free, err := diskFree(d.dir)
+	if err != nil {
+		d.c.reportError(fmt.Errorf("disk watchdog: %v", err))
+		return
+	}
+	if free >= min {
+		return
+	}
+
+	type rotated struct {
+		path string
+		mod  time.Time
+	}
+	var files []rotated
+	for _, path := range d.c.rotatedFiles() {
+		if info, err := os.Stat(path); err == nil {
+			files = append(files, rotated{path, info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+
+	var purged []string
+	for _, f := range files {
+		if free >= min {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			d.c.reportError(err)
+			continue
+		}
+		purged = append(purged, f.path)
+		if free, err = diskFree(d.dir); err != nil {
+			break
+		}
+	}
+	if d.opts.OnLowSpace != nil {
+		d.opts.OnLowSpace(int64(free>>20), purged)
+	}
+}
+
+// Close stops the watchdog
+func (d *diskWatch) Close() {
+	d.once.Do(func() { close(d.stop) })
+	<-d.done
+}
+
+// diskFree returns the bytes available on the file system holding dir
+func diskFree(dir string) (uint64, error) {
+	free, _, err := diskUsage(dir)
+	return free, err
+}
@@ -0,0 +1,103 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ECSVersion is the Elastic Common Schema version ECSFormatter follows
+const ECSVersion = "8.11.0"
+
+// ecsFieldMap renames the fields this package and common callers add to
+// their ECS equivalents
+var ecsFieldMap = map[string]string{
+	logrus.ErrorKey: "error.message",
+	"hostname":      "host.hostname",
+	"pid":           "process.pid",
+	"service":       "service.name",
+	"version":       "service.version",
+	"trace_id":      "trace.id",
+	"span_id":       "span.id",
+}
+
+// ECSFormatter writes entries as Elastic Common Schema JSON documents:
+// @timestamp, log.level, message and ecs.version, with dotted field names
+// such as "http.request.method" expanded into nested objects
+type ECSFormatter struct {
+	FieldMap map[string]string // extra field renames, e.g. "user" to "user.name"
+}
+
+// Format implements logrus.Formatter
+func (f *ECSFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // so "a" always lands before "a.b" and the output is stable
+
+	doc := map[string]interface{}{}
+	for _, k := range keys {
+		v := e.Data[k]
+		if name, ok := f.FieldMap[k]; ok {
+			k = name
+		} else if name, ok := ecsFieldMap[k]; ok {
+			k = name
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		ecsSet(doc, k, v)
+	}
+	if err, ok := e.Data[logrus.ErrorKey].(error); ok {
+		ecsSet(doc, "error.type", fmt.Sprintf("%T", err))
+	}
+
+	// The fixed fields win over entry fields of the same name
+	doc["@timestamp"] = e.Time.Format("2006-01-02T15:04:05.000Z07:00")
+	doc["message"] = e.Message
+	ecsSet(doc, "log.level", e.Level.String())
+	ecsSet(doc, "ecs.version", ECSVersion)
+	if e.HasCaller() {
+		ecsSet(doc, "log.origin.function", e.Caller.Function)
+		ecsSet(doc, "log.origin.file.name", e.Caller.File)
+		ecsSet(doc, "log.origin.file.line", e.Caller.Line)
+	}
+
+	b := e.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// ecsSet stores v under the dotted key, creating nested objects on the way.
+// When a parent is already a plain value the rest of the key is kept dotted
+func ecsSet(doc map[string]interface{}, key string, v interface{}) {
+	parts := strings.Split(key, ".")
+	m := doc
+	for i, p := range parts[:len(parts)-1] {
+		switch next := m[p].(type) {
+		case map[string]interface{}:
+			m = next
+			continue
+		case nil:
+			child := map[string]interface{}{}
+			m[p] = child
+			m = child
+			continue
+		}
+		m[strings.Join(parts[i:], ".")] = v
+		return
+	}
+	m[parts[len(parts)-1]] = v
+}
@@ -0,0 +1,102 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ElasticsearchOptions configures the Elasticsearch bulk output
+type ElasticsearchOptions struct {
+	URL         string           // Elasticsearch base URL, e.g. http://es:9200
+	IndexPrefix string           // daily indices are named <prefix>-2006.01.02, defaults to "logs"
+	Username    string           // basic auth user
+	Password    string           // basic auth password
+	APIKey      string           // API key, used instead of basic auth when set
+	Formatter   logrus.Formatter // document formatter, must produce JSON, defaults to JSON with @timestamp
+	Batch       BatchOptions     // set Batch.MaxInFlight to send several bulk requests at once
+	Client      *http.Client     // defaults to a client with a 10s timeout
+}
+
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// newElasticsearchWriter returns a batching writer sending _bulk requests
+// into one index per UTC day
+func newElasticsearchWriter(opts ElasticsearchOptions) (*batcher, logrus.Formatter) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.IndexPrefix == "" {
+		opts.IndexPrefix = "logs"
+	}
+	formatter := opts.Formatter
+	if formatter == nil {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyTime: "@timestamp"},
+		}
+	}
+	url := strings.TrimRight(opts.URL, "/") + "/_bulk"
+
+	return newBatcher(opts.Batch, func(batch []batchRecord) error {
+		var body bytes.Buffer
+		for _, rec := range batch {
+			fmt.Fprintf(&body, `{"index":{"_index":"%s-%s"}}`+"\n", opts.IndexPrefix, rec.time.UTC().Format("2006.01.02"))
+			body.Write(rec.line)
+			body.WriteByte('\n')
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, &body)
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if opts.APIKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+opts.APIKey)
+		} else if opts.Username != "" {
+			req.SetBasicAuth(opts.Username, opts.Password)
+		}
+
+		resp, err := opts.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return fmt.Errorf("elasticsearch bulk: %s", resp.Status)
+		}
+		if resp.StatusCode >= 300 {
+			return permanentError{fmt.Errorf("elasticsearch bulk: %s", resp.Status)}
+		}
+
+		// The request succeeds as a whole even when single documents are
+		// rejected, retrying would duplicate the accepted ones
+		var result esBulkResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.Errors {
+			return nil
+		}
+		failed := 0
+		var first json.RawMessage
+		for _, item := range result.Items {
+			for _, r := range item {
+				if r.Status >= 300 {
+					if failed++; first == nil {
+						first = r.Error
+					}
+				}
+			}
+		}
+		return permanentError{fmt.Errorf("elasticsearch bulk: %d of %d documents rejected: %s", failed, len(batch), first)}
+	}), formatter
+}
@@ -0,0 +1,190 @@
+package hybridlog
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Encrypted files are a sequence of self-contained records, one per write
+// of up to encryptChunk bytes, so they survive rotation and restarts:
+//
+//	version (1) | key id length (1) | key id | nonce (12) | length (4) | AES-GCM sealed data
+//
+// The version and key id are authenticated as additional data
+const (
+	encryptVersion = 1
+	encryptChunk   = 64 * 1024
+)
+
+// KeyProvider supplies AES keys (16, 24 or 32 bytes) for log encryption.
+// CurrentKey is called for every record and should be cheap, the id it
+// returns is stored with the record to find the key again with Key
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// StaticKey returns a KeyProvider for a single key with an empty id
+func StaticKey(key []byte) KeyProvider {
+	return staticKey(key)
+}
+
+type staticKey []byte
+
+func (k staticKey) CurrentKey() (string, []byte, error) { return "", k, nil }
+
+func (k staticKey) Key(id string) ([]byte, error) {
+	if id != "" {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	return k, nil
+}
+
+// aeadCache keeps the cipher of each key id
+type aeadCache struct {
+	keys  KeyProvider
+	mu    sync.Mutex
+	aeads map[string]cipher.AEAD
+}
+
+func (c *aeadCache) get(id string, key []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if aead, ok := c.aeads[id]; ok {
+		return aead, nil
+	}
+	if key == nil {
+		var err error
+		if key, err = c.keys.Key(id); err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if c.aeads == nil {
+		c.aeads = map[string]cipher.AEAD{}
+	}
+	c.aeads[id] = aead
+	return aead, nil
+}
+
+// encrypter seals the data written to a file into records
+type encrypter struct {
+	aeads aeadCache
+}
+
+func newEncrypter(keys KeyProvider) *encrypter {
+	return &encrypter{aeads: aeadCache{keys: keys}}
+}
+
+func (e *encrypter) seal(p []byte) ([]byte, error) {
+	id, key, err := e.aeads.keys.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key: %v", err)
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("encryption key id %q is too long", id)
+	}
+	aead, err := e.aeads.get(id, key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %v", err)
+	}
+
+	head := append([]byte{encryptVersion, byte(len(id))}, id...)
+	var out []byte
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > encryptChunk {
+			chunk = chunk[:encryptChunk]
+		}
+		p = p[len(chunk):]
+
+		out = append(out, head...)
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		out = append(out, nonce...)
+		out = binary.BigEndian.AppendUint32(out, uint32(len(chunk)+aead.Overhead()))
+		out = aead.Seal(out, nonce, chunk, head)
+	}
+	return out, nil
+}
+
+// NewDecryptReader returns the plain text of an encrypted log file. A
+// record cut short, e.g. by a crash during a write, ends it with
+// io.ErrUnexpectedEOF
+func NewDecryptReader(r io.Reader, keys KeyProvider) io.Reader {
+	return &decryptReader{r: bufio.NewReader(r), aeads: aeadCache{keys: keys}}
+}
+
+type decryptReader struct {
+	r     *bufio.Reader
+	aeads aeadCache
+	buf   []byte // decrypted data not read yet
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// next decrypts the next record into buf
+func (d *decryptReader) next() error {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(d.r, head); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return io.ErrUnexpectedEOF
+	}
+	if head[0] != encryptVersion {
+		return fmt.Errorf("not an encrypted log record (version %d)", head[0])
+	}
+	id := make([]byte, head[1])
+	if _, err := io.ReadFull(d.r, id); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	head = append(head, id...)
+
+	aead, err := d.aeads.get(string(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to get decryption key: %v", err)
+	}
+	nonce := make([]byte, aead.NonceSize()+4)
+	if _, err := io.ReadFull(d.r, nonce); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	size := binary.BigEndian.Uint32(nonce[aead.NonceSize():])
+	if size > encryptChunk+uint32(aead.Overhead()) {
+		return fmt.Errorf("corrupt encrypted log record (%d bytes)", size)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if d.buf, err = aead.Open(sealed[:0], nonce[:aead.NonceSize()], sealed, head); err != nil {
+		return fmt.Errorf("failed to decrypt log record: %v", err)
+	}
+	return nil
+}
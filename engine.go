@@ -0,0 +1,40 @@
+package hybridlog
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Engine encodes entries in place of a logrus formatter. The hybridlogzap and
+// hybridlogzerolog modules back it with the encoders of zap and zerolog,
+// which write the fields without the intermediate map and encoding/json of
+// logrus' JSON formatter. Levels, hooks, rotation and file names work as
+// with any formatter
+type Engine interface {
+	// Encode writes the entry to w as one line ending in a newline
+	Encode(w io.Writer, e *logrus.Entry) error
+}
+
+// engineFormatter adapts an Engine to the formatter of the files and outputs
+type engineFormatter struct {
+	engine Engine
+}
+
+// Format implements logrus.Formatter
+func (f *engineFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	b := e.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	if err := f.engine.Encode(b, e); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// SetEngine replaces the file formatter with engine at runtime
+func (h *HybridLogger) SetEngine(engine Engine) {
+	h.SetFileFormatter(&engineFormatter{engine: engine})
+}
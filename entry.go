@@ -0,0 +1,58 @@
+package hybridlog
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a log entry with fields attached. It keeps a reference to the
+// HybridLogger it came from so chained calls stay in this package's API
+type Entry struct {
+	*logrus.Entry
+	h *HybridLogger
+}
+
+func (h *HybridLogger) newEntry(e *logrus.Entry) *Entry {
+	return &Entry{Entry: e, h: h}
+}
+
+// WithField adds a single field to the entry
+func (h *HybridLogger) WithField(key string, value interface{}) *Entry {
+	return h.newEntry(h.Logger.WithField(key, lazyField(value)))
+}
+
+// WithFields adds a map of fields to the entry
+func (h *HybridLogger) WithFields(fields logrus.Fields) *Entry {
+	return h.newEntry(h.Logger.WithFields(lazyFields(fields)))
+}
+
+// WithError adds an error as the "error" field to the entry
+func (h *HybridLogger) WithError(err error) *Entry {
+	return h.newEntry(h.Logger.WithError(err))
+}
+
+// WithTime creates an entry with the given time instead of time.Now
+func (h *HybridLogger) WithTime(t time.Time) *Entry {
+	return h.newEntry(h.Logger.WithTime(t))
+}
+
+// WithField adds a single field to the entry
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return e.h.newEntry(e.Entry.WithField(key, lazyField(value)))
+}
+
+// WithFields adds a map of fields to the entry
+func (e *Entry) WithFields(fields logrus.Fields) *Entry {
+	return e.h.newEntry(e.Entry.WithFields(lazyFields(fields)))
+}
+
+// WithError adds an error as the "error" field to the entry
+func (e *Entry) WithError(err error) *Entry {
+	return e.h.newEntry(e.Entry.WithError(err))
+}
+
+// WithTime overrides the time of the entry
+func (e *Entry) WithTime(t time.Time) *Entry {
+	return e.h.newEntry(e.Entry.WithTime(t))
+}
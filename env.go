@@ -0,0 +1,117 @@
+package hybridlog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// InitFromEnv initializes the logger from opts with the environment
+// variables of ApplyEnv taking precedence, e.g. InitFromEnv("LOG") reads
+// LOG_LEVEL, LOG_DIR, LOG_MAX_SIZE_MB and so on
+func InitFromEnv(prefix string, opts ...Option) (*HybridLogger, error) {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.ApplyEnv(prefix); err != nil {
+		return nil, err
+	}
+	return InitWithOptions(o)
+}
+
+// ApplyEnv overrides options with the environment variables that are set,
+// named prefix_ followed by LEVEL, DIR, FILE_NAME, MAX_SIZE_MB, MAX_BACKUPS,
+// MAX_AGE_DAYS, MAX_TOTAL_SIZE_MB, MAX_LINES, COMPRESS, ROTATION, ROTATE_AT,
+// FORMAT, CONSOLE, ASYNC and REPORT_CALLER. Values are written as in Config,
+// booleans as accepted by strconv.ParseBool. Apply it to Config.Options to
+// let the environment override a config file
+func (o *Options) ApplyEnv(prefix string) error {
+	if prefix != "" && prefix[len(prefix)-1] != '_' {
+		prefix += "_"
+	}
+	env := func(name string) (string, string, bool) {
+		v, ok := os.LookupEnv(prefix + name)
+		return prefix + name, v, ok && v != ""
+	}
+
+	strs := []struct {
+		name string
+		dst  *string
+	}{
+		{"DIR", &o.LogDir},
+		{"FILE_NAME", &o.FileName},
+	}
+	for _, s := range strs {
+		if _, v, ok := env(s.name); ok {
+			*s.dst = v
+		}
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"MAX_SIZE_MB", &o.MaxSizeMB},
+		{"MAX_BACKUPS", &o.MaxBackups},
+		{"MAX_AGE_DAYS", &o.MaxAgeDays},
+		{"MAX_TOTAL_SIZE_MB", &o.MaxTotalSizeMB},
+		{"MAX_LINES", &o.MaxLines},
+	}
+	for _, i := range ints {
+		if key, v, ok := env(i.name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: want a whole number, got %q", key, v)
+			}
+			*i.dst = n
+		}
+	}
+
+	bools := []struct {
+		name string
+		dst  *bool
+	}{
+		{"COMPRESS", &o.Compress},
+		{"CONSOLE", &o.ConsoleOutput},
+		{"ASYNC", &o.Async},
+		{"REPORT_CALLER", &o.ReportCaller},
+	}
+	for _, b := range bools {
+		if key, v, ok := env(b.name); ok {
+			on, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: want true or false, got %q", key, v)
+			}
+			*b.dst = on
+		}
+	}
+
+	if key, v, ok := env("LEVEL"); ok {
+		if _, err := ParseLevel(v); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		o.LevelName = v
+	}
+	if key, v, ok := env("ROTATION"); ok {
+		d, err := parseRotation(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		o.RotationInterval = d
+	}
+	if key, v, ok := env("ROTATE_AT"); ok {
+		if _, err := parseRotateAt(v); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		o.RotateAt = v
+	}
+	if key, v, ok := env("FORMAT"); ok {
+		f, err := parseFormatName(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		o.Format, o.Formatter = f, nil
+	}
+	return nil
+}
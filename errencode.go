@@ -0,0 +1,99 @@
+package hybridlog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorEncoder turns the error of an entry, set with WithError, into the
+// fields that replace the "error" field
+type ErrorEncoder func(err error) logrus.Fields
+
+// ErrorCause is one error wrapped by the logged one
+type ErrorCause struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// DefaultErrorEncoder keeps the message as "error" and adds "error.type",
+// "error.causes" with the errors it wraps, through Unwrap and errors.Join,
+// and "error.stack" from the innermost error carrying a stack trace, such as
+// those of github.com/pkg/errors
+func DefaultErrorEncoder(err error) logrus.Fields {
+	fields := logrus.Fields{
+		logrus.ErrorKey: err.Error(),
+		"error.type":    fmt.Sprintf("%T", err),
+	}
+	var causes []ErrorCause
+	stack := errorStack(err)
+	walkErrors(err, func(cause error) {
+		causes = append(causes, ErrorCause{Type: fmt.Sprintf("%T", cause), Message: cause.Error()})
+		if s := errorStack(cause); s != "" {
+			stack = s
+		}
+	})
+	if len(causes) > 0 {
+		fields["error.causes"] = causes
+	}
+	if stack != "" {
+		fields["error.stack"] = stack
+	}
+	return fields
+}
+
+// walkErrors calls fn for every error wrapped by err, depth first
+func walkErrors(err error, fn func(error)) {
+	var wrapped []error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if w := u.Unwrap(); w != nil {
+			wrapped = []error{w}
+		}
+	case interface{ Unwrap() []error }:
+		wrapped = u.Unwrap()
+	}
+	for _, w := range wrapped {
+		fn(w)
+		walkErrors(w, fn)
+	}
+}
+
+// errorStack formats the stack of errors with a StackTrace method returning
+// frames that print themselves with %+v, as github.com/pkg/errors does,
+// without depending on it
+func errorStack(err error) string {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 || m.Type().Out(0).Kind() != reflect.Slice {
+		return ""
+	}
+	frames := m.Call(nil)[0]
+	var b strings.Builder
+	for i := 0; i < frames.Len(); i++ {
+		if f, ok := frames.Index(i).Interface().(fmt.Formatter); ok {
+			fmt.Fprintf(&b, "%+v\n", f)
+		}
+	}
+	return strings.TrimPrefix(b.String(), "\n")
+}
+
+// errorHook encodes the error of entries
+type errorHook struct {
+	encode ErrorEncoder
+}
+
+func (h *errorHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *errorHook) Fire(e *logrus.Entry) error {
+	err, ok := e.Data[logrus.ErrorKey].(error)
+	if !ok || isDropped(e) {
+		return nil
+	}
+	delete(e.Data, logrus.ErrorKey)
+	for k, v := range h.encode(err) {
+		e.Data[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package hybridlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Errors returned by Init for invalid options, test for them with errors.Is
+var (
+	ErrInvalidLevel  = errors.New("invalid log level")
+	ErrInvalidSize   = errors.New("invalid size or count")
+	ErrBadFileName   = errors.New("bad log file name")
+	ErrUnwritableDir = errors.New("log dir is not writable")
+)
+
+// OnError sets a callback for the errors logging can't return to its caller:
+// failed file writes, failed outputs and uploads and dropped batches. It runs
+// on the goroutine that hit the error and must not log through h
+func (h *HybridLogger) OnError(fn func(error)) {
+	h.errMu.Lock()
+	defer h.errMu.Unlock()
+
+	h.onError = fn
+}
+
+// ErrorCount returns how many such errors occurred since Init
+func (h *HybridLogger) ErrorCount() uint64 {
+	return h.errCount.Load()
+}
+
+// reportError counts err and passes it to the OnError callback
+func (c *core) reportError(err error) {
+	c.errCount.Add(1)
+
+	c.errMu.Lock()
+	c.lastErr, c.lastErrAt = err, time.Now()
+	fn := c.onError
+	c.errMu.Unlock()
+
+	if fn != nil {
+		fn(err)
+	}
+}
+
+// writeFile writes p to the main file, reporting failures
+func (c *core) writeFile(p []byte) (int, error) {
+	n, err := c.fileOut.Write(p)
+	if err != nil {
+		c.reportError(err)
+	}
+	return n, err
+}
+
+// errorReporter is implemented by outputs that fail in the background, such
+// as the batching network outputs
+type errorReporter interface {
+	setErrorHandler(fn func(error))
+}
+
+// validateOptions rejects the options Init can't work with
+func validateOptions(opts Options) error {
+	if _, ok := levelMap[opts.Level]; !ok {
+		return fmt.Errorf("%w %d, want 0 (panic) to 6 (trace)", ErrInvalidLevel, opts.Level)
+	}
+	if opts.LevelName != "" {
+		if _, err := ParseLevel(opts.LevelName); err != nil {
+			return err
+		}
+	}
+	if st := opts.StackTraces; st != nil && st.Level != nil {
+		if _, ok := levelMap[int(*st.Level)]; !ok {
+			return fmt.Errorf("%w %d for stack traces, want 0 (panic) to 6 (trace)", ErrInvalidLevel, *st.Level)
+		}
+	}
+	sizes := []struct {
+		name  string
+		value int
+	}{
+		{"MaxSizeMB", opts.MaxSizeMB},
+		{"MaxBackups", opts.MaxBackups},
+		{"MaxAgeDays", opts.MaxAgeDays},
+		{"MaxTotalSizeMB", opts.MaxTotalSizeMB},
+		{"MaxMessageBytes", opts.MaxMessageBytes},
+		{"MaxFieldBytes", opts.MaxFieldBytes},
+	}
+	for _, s := range sizes {
+		if s.value < 0 {
+			return fmt.Errorf("%w: %s is %d", ErrInvalidSize, s.name, s.value)
+		}
+	}
+
+	var names []string
+	if !opts.NoFile {
+		names = append(names, opts.FileName)
+	}
+	if opts.ErrorFile != nil && opts.ErrorFile.FileName != "" {
+		names = append(names, opts.ErrorFile.FileName)
+	}
+	if opts.HTTPAccess != nil && opts.HTTPAccess.File != nil && opts.HTTPAccess.File.FileName != "" {
+		names = append(names, opts.HTTPAccess.File.FileName)
+	}
+	if opts.Audit != nil && opts.Audit.File.FileName != "" {
+		names = append(names, opts.Audit.File.FileName)
+	}
+	namer, err := newFileNamer(opts.FileNameTemplate)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := validateFileName(name); err != nil {
+			return err
+		}
+		if _, err := namer.name(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFileName checks a log file name is a plain name, the date and
+// backup timestamps are added before its extension
+func validateFileName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("%w: the name is empty", ErrBadFileName)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%w %q: use LogDir for the directory", ErrBadFileName, name)
+	case strings.Trim(name, ".") == "" || filepath.Ext(name) == name:
+		return fmt.Errorf("%w %q: the name is only an extension", ErrBadFileName, name)
+	case strings.Count(name, dateToken) > 1:
+		return fmt.Errorf("%w %q: the date can only appear once", ErrBadFileName, name)
+	}
+	return nil
+}
+
+// checkWritable creates and removes a file in dir
+func checkWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".hybridlog-*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnwritableDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
@@ -0,0 +1,48 @@
+package hybridlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"level too high", []Option{WithLevel(7)}, ErrInvalidLevel},
+		{"negative level", []Option{WithLevel(-1)}, ErrInvalidLevel},
+		{"negative size", []Option{WithMaxSize(-1)}, ErrInvalidSize},
+		{"negative backups", []Option{WithMaxBackups(-1)}, ErrInvalidSize},
+		{"negative field limit", []Option{WithTruncate(0, -1)}, ErrInvalidSize},
+		{"stack level too high", []Option{withStackLevel(7)}, ErrInvalidLevel},
+		{"empty name", []Option{WithFileName(" ")}, ErrBadFileName},
+		{"name with dir", []Option{WithFileName("logs/app.log")}, ErrBadFileName},
+		{"only extension", []Option{WithFileName(".log")}, ErrBadFileName},
+		{"two dates", []Option{WithFileName("app-{date}-{date}.log")}, ErrBadFileName},
+		{"access log with dir", []Option{WithHTTPAccess(HTTPAccessOptions{File: &FileOptions{FileName: "logs/access.log"}})}, ErrBadFileName},
+		{"audit only extension", []Option{WithAudit(AuditOptions{File: FileOptions{FileName: ".log"}})}, ErrBadFileName},
+		{"audit default name", []Option{WithAudit(AuditOptions{})}, nil},
+		{"dir under a file", []Option{WithLogDir(filepath.Join(file, "logs"))}, ErrUnwritableDir},
+		{"valid", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithLogDir(t.TempDir())}, tt.opts...)
+			h, err := New(opts...)
+			if err == nil {
+				h.Close()
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package hybridlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// fallbackWriter writes to a log file, switching to another writer (stderr
+// by default) while the file fails and trying the file again every retry
+type fallbackWriter struct {
+	file     *rotatingFile
+	fallback io.Writer
+	retry    time.Duration
+	report   func(error)
+
+	mu      sync.Mutex
+	retryAt time.Time // zero while the file works
+}
+
+func (w *fallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.retryAt.IsZero() && time.Now().Before(w.retryAt) {
+		return w.fallback.Write(p)
+	}
+	n, err := w.file.Write(p)
+	if err == nil || errors.Is(err, os.ErrClosed) {
+		w.retryAt = time.Time{}
+		return n, err
+	}
+
+	w.report(fmt.Errorf("log file failed, writing to fallback for %v: %v", w.retry, err))
+	w.retryAt = time.Now().Add(w.retry)
+	return w.fallback.Write(p)
+}
+
+// fileWriter returns the writer for f, with a fallback unless disabled and
+// buffered if enabled. Encrypted files have no fallback, it would get the
+// entries in plaintext
+func (c *core) fileWriter(f *rotatingFile, opts Options) io.Writer {
+	if opts.NoFallback || opts.EncryptionKeys != nil {
+		return c.buffered(f, opts)
+	}
+	fallback := opts.FallbackWriter
+	if fallback == nil {
+		fallback = os.Stderr
+	}
+	retry := opts.FallbackRetryInterval
+	if retry <= 0 {
+		retry = 10 * time.Second
+	}
+	return c.buffered(&fallbackWriter{file: f, fallback: fallback, retry: retry, report: c.reportError}, opts)
+}
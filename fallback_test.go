@@ -0,0 +1,52 @@
+package hybridlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		plaintext bool
+	}{
+		{"plain file", nil, true},
+		{"encrypted file", []Option{WithEncryption(StaticKey(bytes.Repeat([]byte("k"), 32)))}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "logs")
+			var fallback bytes.Buffer
+			var failures int
+			opts := append([]Option{
+				WithLogDir(dir),
+				WithFallback(&fallback, time.Hour),
+				WithOnError(func(error) { failures++ }),
+			}, tt.opts...)
+			h, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A file in place of the log dir makes every write fail
+			if err := os.RemoveAll(dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(dir, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			h.Info("secret entry")
+			h.Close()
+
+			if got := bytes.Contains(fallback.Bytes(), []byte("secret entry")); got != tt.plaintext {
+				t.Fatalf("entry in the fallback: %v, want %v", got, tt.plaintext)
+			}
+			if failures == 0 {
+				t.Fatal("the failure wasn't reported")
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FastJSONFormatter writes the same JSON as logrus.JSONFormatter, keys in
+// sorted order, without going through encoding/json and a map for every
+// entry. Strings, numbers, bools, errors, times and durations are encoded
+// by hand, other values still go through encoding/json
+type FastJSONFormatter struct {
+	TimestampFormat   string          // defaults to time.RFC3339, EpochMillis writes a number
+	DisableHTMLEscape bool            // leave <, > and & as they are in strings
+	PrettyPrint       bool            // indent entries over several lines
+	FieldMap          logrus.FieldMap // renames the time, level, msg, func and file keys
+}
+
+// jsonPair is one key of an entry, fixed tells the keys of the entry itself
+// from its fields
+type jsonPair struct {
+	key   string
+	value interface{}
+	fixed byte
+}
+
+const (
+	jsonField byte = iota
+	jsonTime
+	jsonLevel
+	jsonMsg
+	jsonFunc
+	jsonFile
+)
+
+// Format implements logrus.Formatter
+func (f *FastJSONFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	var stack [16]jsonPair
+	pairs := stack[:0]
+	caller := e.HasCaller()
+	keys := resolveFieldKeys(f.FieldMap)
+	for k, v := range e.Data {
+		switch k {
+		case keys.time, keys.level, keys.msg:
+			k = "fields." + k // don't clash with the fixed keys, as logrus does
+		case keys.fn, keys.file:
+			if caller {
+				k = "fields." + k
+			}
+		}
+		pairs = append(pairs, jsonPair{key: k, value: v})
+	}
+	pairs = append(pairs,
+		jsonPair{key: keys.time, fixed: jsonTime},
+		jsonPair{key: keys.level, fixed: jsonLevel},
+		jsonPair{key: keys.msg, fixed: jsonMsg})
+	if caller {
+		pairs = append(pairs,
+			jsonPair{key: keys.fn, fixed: jsonFunc},
+			jsonPair{key: keys.file, fixed: jsonFile})
+	}
+	slices.SortFunc(pairs, func(a, b jsonPair) int { return strings.Compare(a.key, b.key) })
+
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339
+	}
+	escapeHTML := !f.DisableHTMLEscape
+
+	var out []byte
+	if e.Buffer != nil && !f.PrettyPrint {
+		out = e.Buffer.AvailableBuffer()
+	} else {
+		out = make([]byte, 0, 512)
+	}
+	out = append(out, '{')
+	for i, p := range pairs {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = appendJSONString(out, p.key, escapeHTML)
+		out = append(out, ':')
+		switch p.fixed {
+		case jsonTime:
+			if timestampFormat == EpochMillis {
+				out = strconv.AppendInt(out, e.Time.UnixMilli(), 10)
+				break
+			}
+			out = append(out, '"')
+			out = e.Time.AppendFormat(out, timestampFormat)
+			out = append(out, '"')
+		case jsonLevel:
+			out = appendJSONString(out, e.Level.String(), escapeHTML)
+		case jsonMsg:
+			out = appendJSONString(out, e.Message, escapeHTML)
+		case jsonFunc:
+			out = appendJSONString(out, e.Caller.Function, escapeHTML)
+		case jsonFile:
+			out = appendJSONString(out, e.Caller.File, escapeHTML)
+			out = out[:len(out)-1]
+			out = append(out, ':')
+			out = strconv.AppendInt(out, int64(e.Caller.Line), 10)
+			out = append(out, '"')
+		default:
+			var err error
+			if out, err = appendJSONValue(out, p.value, escapeHTML); err != nil {
+				return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+			}
+		}
+	}
+	out = append(out, '}', '\n')
+
+	if f.PrettyPrint {
+		b := e.Buffer
+		if b == nil {
+			b = &bytes.Buffer{}
+		}
+		if err := json.Indent(b, out[:len(out)-1], "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+		}
+		b.WriteByte('\n')
+		return b.Bytes(), nil
+	}
+	if e.Buffer != nil {
+		e.Buffer.Write(out) // no copy when out still fits the buffer
+		return e.Buffer.Bytes(), nil
+	}
+	return out, nil
+}
+
+// appendJSONValue appends v as encoding/json would
+func appendJSONValue(b []byte, v interface{}, escapeHTML bool) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendJSONString(b, v, escapeHTML), nil
+	case error:
+		return appendJSONString(b, v.Error(), escapeHTML), nil // as logrus does
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(b, v, 10), nil
+	case time.Duration:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return appendJSONFloat(b, v, 64), nil
+		}
+	case float32:
+		if f := float64(v); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return appendJSONFloat(b, f, 32), nil
+		}
+	case time.Time:
+		if y := v.Year(); y >= 0 && y <= 9999 {
+			b = append(b, '"')
+			b = v.AppendFormat(b, time.RFC3339Nano)
+			return append(b, '"'), nil
+		}
+	}
+
+	// Everything else, and the values encoding/json rejects so that the
+	// error is the same
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return b, err
+	}
+	return append(b, strings.TrimSuffix(sb.String(), "\n")...), nil
+}
+
+// appendJSONFloat formats f the way encoding/json does
+func appendJSONFloat(b []byte, f float64, bits int) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// appendJSONString appends s quoted and escaped the way encoding/json does
+func appendJSONString(b []byte, s string, escapeHTML bool) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && (!escapeHTML || c != '<' && c != '>' && c != '&') {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' { // valid JSON but not JavaScript
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
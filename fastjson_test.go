@@ -0,0 +1,122 @@
+package hybridlog
+
+import (
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// formatEntry formats an entry at a fixed time with the given fields, a
+// failure is returned as its error message
+func formatEntry(f logrus.Formatter, fields logrus.Fields, caller bool) string {
+	l := logrus.New()
+	e := logrus.NewEntry(l).WithFields(fields)
+	e.Time = time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("X", 3600))
+	e.Level = logrus.WarnLevel
+	e.Message = "hello <world> & \"friends\"\n"
+	if caller {
+		e.Caller = &runtime.Frame{Function: "main.run", File: "/src/main.go", Line: 42}
+		l.ReportCaller = true
+	}
+	b, err := f.Format(e)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return string(b)
+}
+
+func TestFastJSONMatchesLogrus(t *testing.T) {
+	fields := logrus.Fields{
+		"string":   "tab\there, quote \" and \\ back\u2028",
+		"invalid":  "bad \xff utf8",
+		"control":  "\x01\x1f",
+		"int":      -42,
+		"uint8":    uint8(200),
+		"float":    3.25,
+		"tiny":     1e-9,
+		"huge":     1e21,
+		"float32":  float32(0.1),
+		"nan":      math.NaN(),
+		"bool":     true,
+		"nil":      nil,
+		"error":    errors.New("boom"),
+		"duration": 1500 * time.Millisecond,
+		"time":     time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		"map":      map[string]int{"b": 2, "a": 1},
+		"slice":    []string{"x", "<y>"},
+		"struct":   struct{ A int }{1},
+		"msg":      "clashes with the message key",
+	}
+	fieldMap := logrus.FieldMap{
+		logrus.FieldKeyTime:  "@timestamp",
+		logrus.FieldKeyLevel: "severity",
+		logrus.FieldKeyMsg:   "message",
+	}
+	tests := []struct {
+		name   string
+		fast   *FastJSONFormatter
+		logrus *logrus.JSONFormatter
+		caller bool
+	}{
+		{"defaults", &FastJSONFormatter{}, &logrus.JSONFormatter{}, false},
+		{"caller", &FastJSONFormatter{}, &logrus.JSONFormatter{}, true},
+		{"timestamp format", &FastJSONFormatter{TimestampFormat: time.RFC3339Nano}, &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}, false},
+		{"no html escape", &FastJSONFormatter{DisableHTMLEscape: true}, &logrus.JSONFormatter{DisableHTMLEscape: true}, false},
+		{"field map", &FastJSONFormatter{FieldMap: fieldMap}, &logrus.JSONFormatter{FieldMap: fieldMap}, true},
+		{"pretty", &FastJSONFormatter{PrettyPrint: true}, &logrus.JSONFormatter{PrettyPrint: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range fields {
+				one := logrus.Fields{k: v}
+				got := formatEntry(tt.fast, one, tt.caller)
+				want := formatEntry(tt.logrus, one, tt.caller)
+				if got != want {
+					t.Errorf("field %s:\ngot  %s\nwant %s", k, got, want)
+				}
+			}
+			all := logrus.Fields{}
+			for k, v := range fields {
+				if k != "nan" { // fails the whole entry
+					all[k] = v
+				}
+			}
+			got := formatEntry(tt.fast, all, tt.caller)
+			want := formatEntry(tt.logrus, all, tt.caller)
+			if got != want {
+				t.Errorf("all fields:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func benchmarkJSON(b *testing.B, f logrus.Formatter) {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(f)
+	e := l.WithFields(logrus.Fields{
+		"user":     "alice",
+		"id":       12345,
+		"elapsed":  250 * time.Millisecond,
+		"ok":       true,
+		"endpoint": "/api/v1/items",
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Info("request handled")
+	}
+}
+
+func BenchmarkFastJSON(b *testing.B) {
+	benchmarkJSON(b, &FastJSONFormatter{})
+}
+
+func BenchmarkLogrusJSON(b *testing.B) {
+	benchmarkJSON(b, &logrus.JSONFormatter{})
+}
@@ -0,0 +1,119 @@
+package hybridlog
+
+import (
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServiceFields returns the usual static fields for a service: hostname,
+// pid, service name and build version
+func ServiceFields(service, version string) logrus.Fields {
+	host, _ := os.Hostname()
+	return logrus.Fields{
+		"hostname": host,
+		"pid":      os.Getpid(),
+		"service":  service,
+		"version":  version,
+	}
+}
+
+// SetGlobalFields replaces the fields added to every entry
+func (h *HybridLogger) SetGlobalFields(fields map[string]interface{}) {
+	copied := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+
+	h.fieldsMu.Lock()
+	defer h.fieldsMu.Unlock()
+
+	h.globalFields = copied
+}
+
+// AddGlobalFields adds to the fields added to every entry
+func (h *HybridLogger) AddGlobalFields(fields map[string]interface{}) {
+	h.fieldsMu.Lock()
+	defer h.fieldsMu.Unlock()
+
+	copied := make(logrus.Fields, len(h.globalFields)+len(fields))
+	for k, v := range h.globalFields {
+		copied[k] = v
+	}
+	for k, v := range fields {
+		copied[k] = v
+	}
+	h.globalFields = copied
+}
+
+// AddDynamicField adds a field to every entry whose value fn computes as the
+// entry is logged, e.g. the goroutine count or the state of a feature flag.
+// fn is only called for entries at an enabled level, and must be safe to call
+// from several goroutines
+func (h *HybridLogger) AddDynamicField(name string, fn func() interface{}) {
+	h.fieldsMu.Lock()
+	defer h.fieldsMu.Unlock()
+
+	copied := make(map[string]func() interface{}, len(h.dynamicFields)+1)
+	for k, v := range h.dynamicFields {
+		copied[k] = v
+	}
+	copied[name] = fn
+	h.dynamicFields = copied
+}
+
+// RemoveDynamicField stops adding a field added with AddDynamicField
+func (h *HybridLogger) RemoveDynamicField(name string) {
+	h.fieldsMu.Lock()
+	defer h.fieldsMu.Unlock()
+
+	copied := make(map[string]func() interface{}, len(h.dynamicFields))
+	for k, v := range h.dynamicFields {
+		if k != name {
+			copied[k] = v
+		}
+	}
+	h.dynamicFields = copied
+}
+
+// globalFieldsHook adds the global and dynamic fields to entries, explicit
+// fields take precedence
+type globalFieldsHook struct {
+	c *core
+}
+
+func (g *globalFieldsHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (g *globalFieldsHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil // dynamic fields may be costly
+	}
+	g.c.fieldsMu.RLock()
+	fields, dynamic := g.c.globalFields, g.c.dynamicFields
+	g.c.fieldsMu.RUnlock()
+
+	for k, v := range fields {
+		if _, ok := e.Data[k]; !ok {
+			e.Data[k] = v
+		}
+	}
+	for k, fn := range dynamic {
+		if _, ok := e.Data[k]; !ok {
+			e.Data[k] = fn()
+		}
+	}
+	return nil
+}
+
+// timezoneHook converts entry timestamps to a fixed timezone before formatting
+type timezoneHook struct {
+	loc *time.Location
+}
+
+func (t *timezoneHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (t *timezoneHook) Fire(e *logrus.Entry) error {
+	e.Time = e.Time.In(t.loc)
+	return nil
+}
@@ -0,0 +1,58 @@
+package hybridlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// FileNameData is what Options.FileNameTemplate is executed with, for the
+// main file and for the files named after it such as app-error.log
+type FileNameData struct {
+	Name    string // file name without its extension, e.g. "app" or "app-error"
+	Ext     string // extension of the file name, e.g. ".log"
+	Date    string // date of the rotation period, in the DatePattern layout
+	Host    string // host name
+	PID     string // process ID
+	Program string // name of the program
+}
+
+// fileNamer lays out file names with a FileNameTemplate. The date and the
+// other values are rendered as the placeholders of Options.FileName, which
+// are filled in when the files are opened
+type fileNamer struct {
+	tmpl *template.Template // nil to use the names as they are
+}
+
+func newFileNamer(text string) (*fileNamer, error) {
+	if text == "" {
+		return &fileNamer{}, nil
+	}
+	tmpl, err := template.New("file name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid FileNameTemplate: %v", ErrBadFileName, err)
+	}
+	return &fileNamer{tmpl: tmpl}, nil
+}
+
+// name returns the name of the file called fileName
+func (n *fileNamer) name(fileName string) (string, error) {
+	if n.tmpl == nil {
+		return fileName, nil
+	}
+	ext := filepath.Ext(fileName)
+	data := FileNameData{
+		Name:    fileName[:len(fileName)-len(ext)],
+		Ext:     ext,
+		Date:    dateToken,
+		Host:    "{host}",
+		PID:     "{pid}",
+		Program: "{name}",
+	}
+	var b strings.Builder
+	if err := n.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: FileNameTemplate: %v", ErrBadFileName, err)
+	}
+	return b.String(), validateFileName(b.String())
+}
@@ -0,0 +1,43 @@
+package hybridlog
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Filter decides whether an entry is written, returning false drops it
+type Filter func(e *logrus.Entry) bool
+
+// AddFilter adds f to the filters every entry must pass, they run in the
+// order added once the entry has its context and global fields
+func (h *HybridLogger) AddFilter(f Filter) {
+	h.filterMu.Lock()
+	defer h.filterMu.Unlock()
+
+	filters := make([]Filter, len(h.filters), len(h.filters)+1)
+	copy(filters, h.filters)
+	h.filters = append(filters, f)
+}
+
+// filterHook drops the entries rejected by a filter
+type filterHook struct {
+	c *core
+}
+
+func (f *filterHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (f *filterHook) Fire(e *logrus.Entry) error {
+	f.c.filterMu.RLock()
+	filters := f.c.filters
+	f.c.filterMu.RUnlock()
+
+	if isDropped(e) {
+		return nil
+	}
+	for _, keep := range filters {
+		if !keep(e) {
+			dropEntry(e)
+			return nil
+		}
+	}
+	return nil
+}
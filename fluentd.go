@@ -0,0 +1,153 @@
+package hybridlog
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FluentdOptions configures the Fluentd forward protocol output
+type FluentdOptions struct {
+	Address      string        // forward input host:port, defaults to 127.0.0.1:24224
+	Tag          string        // event tag, defaults to "app"
+	TagWithLevel bool          // append the level to the tag, e.g. app.error
+	RequireAck   bool          // wait for the server to acknowledge each chunk
+	AckTimeout   time.Duration // defaults to 5s
+	Batch        BatchOptions
+}
+
+// fluentdWriter sends entries in Forward mode: [tag, [[time, record], ...], option]
+type fluentdWriter struct {
+	*batcher
+	opts FluentdOptions
+
+	mu   sync.Mutex // guards conn, used by the batcher goroutines
+	conn net.Conn
+}
+
+func newFluentdWriter(opts FluentdOptions) *fluentdWriter {
+	if opts.Address == "" {
+		opts.Address = "127.0.0.1:24224"
+	}
+	if opts.Tag == "" {
+		opts.Tag = "app"
+	}
+	if opts.AckTimeout <= 0 {
+		opts.AckTimeout = 5 * time.Second
+	}
+	f := &fluentdWriter{opts: opts}
+	f.batcher = newBatcher(opts.Batch, f.send)
+	return f
+}
+
+// WriteEntry queues the entry encoded as a msgpack [time, record] pair
+func (f *fluentdWriter) WriteEntry(e *logrus.Entry) error {
+	record := make(map[string]interface{}, len(e.Data)+4)
+	for k, v := range e.Data {
+		record[k] = v
+	}
+	record[logrus.FieldKeyMsg] = e.Message
+	record[logrus.FieldKeyLevel] = e.Level.String()
+	if e.HasCaller() {
+		record[logrus.FieldKeyFunc] = e.Caller.Function
+		record[logrus.FieldKeyFile] = fmt.Sprintf("%s:%d", e.Caller.File, e.Caller.Line)
+	}
+	b := msgpackAppendArrayHeader(nil, 2)
+	b = msgpackAppendEventTime(b, e.Time)
+	b = msgpackAppend(b, record)
+	return f.enqueue(batchRecord{time: e.Time, level: e.Level, line: b})
+}
+
+func (f *fluentdWriter) tag(level logrus.Level) string {
+	if f.opts.TagWithLevel {
+		return f.opts.Tag + "." + level.String()
+	}
+	return f.opts.Tag
+}
+
+func (f *fluentdWriter) send(batch []batchRecord) error {
+	byTag := map[string][]batchRecord{}
+	var tags []string
+	for _, rec := range batch {
+		tag := f.tag(rec.level)
+		if _, ok := byTag[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		byTag[tag] = append(byTag[tag], rec)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, tag := range tags {
+		if err := f.sendForward(tag, byTag[tag]); err != nil {
+			if f.conn != nil {
+				f.conn.Close()
+				f.conn = nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fluentdWriter) sendForward(tag string, recs []batchRecord) error {
+	if f.conn == nil {
+		conn, err := net.DialTimeout("tcp", f.opts.Address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+
+	msg := msgpackAppendArrayHeader(nil, 3)
+	msg = msgpackAppendString(msg, tag)
+	msg = msgpackAppendArrayHeader(msg, len(recs))
+	for _, rec := range recs {
+		msg = append(msg, rec.line...)
+	}
+	var chunk string
+	if f.opts.RequireAck {
+		id := make([]byte, 16)
+		rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		msg = msgpackAppend(msg, map[string]interface{}{"chunk": chunk, "size": len(recs)})
+	} else {
+		msg = msgpackAppend(msg, map[string]interface{}{"size": len(recs)})
+	}
+
+	f.conn.SetDeadline(time.Now().Add(f.opts.AckTimeout))
+	if _, err := f.conn.Write(msg); err != nil {
+		return err
+	}
+	if !f.opts.RequireAck {
+		return nil
+	}
+	resp, err := msgpackReadStringMap(f.conn)
+	if err != nil {
+		return fmt.Errorf("fluentd ack: %v", err)
+	}
+	if resp["ack"] != chunk {
+		return fmt.Errorf("fluentd ack: got %q, want %q", resp["ack"], chunk)
+	}
+	return nil
+}
+
+// Close sends the remaining entries and closes the connection
+func (f *fluentdWriter) Close() error {
+	err := f.batcher.Close()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package hybridlog
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Format selects one of the built-in formatters
+type Format int
+
+const (
+	FormatJSON     Format = iota // one JSON object per line (default)
+	FormatText                   // logrus text format with full timestamps
+	FormatLogfmt                 // logfmt key=value lines, see LogfmtFormatter
+	FormatECS                    // Elastic Common Schema JSON, see ECSFormatter
+	FormatFastJSON               // FormatJSON's output written faster, see FastJSONFormatter
+)
+
+// EpochMillis is a TimestampFormat writing timestamps as the number of
+// milliseconds since the Unix epoch
+const EpochMillis = "epoch_millis"
+
+// formatOptions are the Options the built-in formatters are created with,
+// ECS keeps its own keys and timestamps
+type formatOptions struct {
+	timestampFormat string
+	fieldKeys       logrus.FieldMap
+	prettyPrint     bool
+}
+
+// newFormatter returns the built-in formatter for f
+func newFormatter(f Format, o formatOptions) (logrus.Formatter, error) {
+	timestampFormat := o.timestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339
+	}
+	switch f {
+	case FormatJSON:
+		if timestampFormat == EpochMillis {
+			// Same output, logrus only writes timestamps as strings
+			return &FastJSONFormatter{TimestampFormat: timestampFormat, FieldMap: o.fieldKeys, PrettyPrint: o.prettyPrint}, nil
+		}
+		return &logrus.JSONFormatter{
+			TimestampFormat: timestampFormat,
+			FieldMap:        o.fieldKeys,
+			PrettyPrint:     o.prettyPrint,
+		}, nil
+	case FormatText:
+		if timestampFormat == EpochMillis {
+			return nil, fmt.Errorf("the text format can't write %s timestamps", EpochMillis)
+		}
+		return &logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: timestampFormat,
+			DisableColors:   true,
+			FieldMap:        o.fieldKeys,
+		}, nil
+	case FormatLogfmt:
+		return &LogfmtFormatter{TimestampFormat: timestampFormat, FieldMap: o.fieldKeys}, nil
+	case FormatECS:
+		return &ECSFormatter{}, nil
+	case FormatFastJSON:
+		return &FastJSONFormatter{TimestampFormat: timestampFormat, FieldMap: o.fieldKeys, PrettyPrint: o.prettyPrint}, nil
+	}
+	return nil, fmt.Errorf("unknown log format: %d", f)
+}
+
+// fieldKeys are the keys of the time, level, message, function and file of
+// entries, once renamed by a logrus.FieldMap
+type fieldKeys struct {
+	time, level, msg, fn, file string
+}
+
+func resolveFieldKeys(m logrus.FieldMap) fieldKeys {
+	keys := fieldKeys{logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, logrus.FieldKeyFunc, logrus.FieldKeyFile}
+	for k, v := range m {
+		switch k {
+		case logrus.FieldKeyTime:
+			keys.time = v
+		case logrus.FieldKeyLevel:
+			keys.level = v
+		case logrus.FieldKeyMsg:
+			keys.msg = v
+		case logrus.FieldKeyFunc:
+			keys.fn = v
+		case logrus.FieldKeyFile:
+			keys.file = v
+		}
+	}
+	return keys
+}
+
+// SetFormat switches the file output to one of the built-in formatters at
+// runtime, any logrus.Formatter can be set with SetFileFormatter
+func (h *HybridLogger) SetFormat(f Format) error {
+	formatter, err := newFormatter(f, h.formatOpts)
+	if err != nil {
+		return err
+	}
+	h.SetFileFormatter(formatter)
+	return nil
+}
+
+// LogfmtFormatter writes entries as logfmt lines: time=... level=... msg=... key=value
+type LogfmtFormatter struct {
+	TimestampFormat string          // defaults to time.RFC3339, EpochMillis for milliseconds since the epoch
+	FieldMap        logrus.FieldMap // renames the time, level, msg, func and file keys
+}
+
+// Format implements logrus.Formatter
+func (f *LogfmtFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	b := e.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339
+	}
+
+	keys := resolveFieldKeys(f.FieldMap)
+	if timestampFormat == EpochMillis {
+		writeLogfmtPair(b, keys.time, strconv.FormatInt(e.Time.UnixMilli(), 10))
+	} else {
+		writeLogfmtPair(b, keys.time, e.Time.Format(timestampFormat))
+	}
+	writeLogfmtPair(b, keys.level, e.Level.String())
+	writeLogfmtPair(b, keys.msg, e.Message)
+	if e.HasCaller() {
+		writeLogfmtPair(b, keys.fn, e.Caller.Function)
+		writeLogfmtPair(b, keys.file, fmt.Sprintf("%s:%d", e.Caller.File, e.Caller.Line))
+	}
+
+	names := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		key := k
+		switch k {
+		case keys.time, keys.level, keys.msg, keys.fn, keys.file:
+			key = "fields." + k // don't clash with the fixed keys
+		}
+		writeLogfmtPair(b, key, logfmtValue(e.Data[k]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func writeLogfmtPair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if logfmtNeedsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+func logfmtValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package hybridlog
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// syncLocked fsyncs the file if anything was written since the last call.
+// Lumberjack doesn't expose its file, fsync on another descriptor of the
+// same file flushes it all the same. The caller holds f.mu
+func (f *rotatingFile) syncLocked() error {
+	if f.pending == 0 {
+		return nil
+	}
+	var file *os.File
+	if f.shared != nil {
+		file = f.shared.file
+	} else {
+		if f.syncFile == nil || f.syncName != f.lumber.Filename {
+			f.dropSyncFile()
+			sf, err := os.OpenFile(f.lumber.Filename, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				return err
+			}
+			f.syncFile, f.syncName = sf, f.lumber.Filename
+			syncDir(filepath.Dir(f.lumber.Filename)) // the new file's directory entry
+		}
+		file = f.syncFile
+	}
+	if file != nil {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	f.pending = 0
+	return nil
+}
+
+// dropSyncFile closes the descriptor used for fsync, after a rotation
+// moved the file it refers to
+func (f *rotatingFile) dropSyncFile() {
+	if f.syncFile != nil {
+		f.syncFile.Close()
+		f.syncFile = nil
+	}
+}
+
+// wrote counts a write, fsyncing every syncEvery writes
+func (f *rotatingFile) wrote() {
+	f.pending++
+	if f.syncEvery > 0 && f.pending >= f.syncEvery {
+		if err := f.syncLocked(); err != nil && f.report != nil {
+			f.report(err)
+		}
+	}
+}
+
+// beforeClose fsyncs what was written to the file before it's closed or
+// rotated, when writes are made durable
+func (f *rotatingFile) beforeClose() {
+	if f.syncEvery > 0 || f.syncInterval {
+		if err := f.syncLocked(); err != nil && f.report != nil {
+			f.report(err)
+		}
+	}
+	f.dropSyncFile()
+}
+
+// syncDir makes a file created in dir survive a crash, best effort as not
+// every platform can sync a directory
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// syncer fsyncs the logger's files at an interval
+type syncer struct {
+	c        *core
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newSyncer(c *core, interval time.Duration) *syncer {
+	s := &syncer{
+		c:        c,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *syncer) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, f := range s.c.files() {
+				if err := f.Sync(); err != nil {
+					s.c.reportError(err)
+				}
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background syncs
+func (s *syncer) Close() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
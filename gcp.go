@@ -0,0 +1,191 @@
+package hybridlog
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNoGCPCredentials is returned when neither a key file nor the metadata
+// server is available
+var errNoGCPCredentials = errors.New("no GCP credentials found")
+
+// GCPTokenSource returns an OAuth2 access token for a request, it is called
+// for every request so it should cache the token
+type GCPTokenSource func() (string, error)
+
+type gcpServiceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type gcpToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// gcpCredentials finds credentials the way the Google client libraries do:
+// the key file in credentialsFile or GOOGLE_APPLICATION_CREDENTIALS, then the
+// metadata server on GCE, GKE and Cloud Run. It also returns the project ID
+// they belong to, if known
+func gcpCredentials(credentialsFile, scope string, client *http.Client) (GCPTokenSource, string, error) {
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if credentialsFile != "" {
+		data, err := os.ReadFile(credentialsFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read GCP credentials: %v", err)
+		}
+		var sa gcpServiceAccount
+		if err := json.Unmarshal(data, &sa); err != nil {
+			return nil, "", fmt.Errorf("failed to parse GCP credentials: %v", err)
+		}
+		if sa.Type != "service_account" {
+			return nil, "", fmt.Errorf("unsupported GCP credentials type %q", sa.Type)
+		}
+		key, err := parseRSAKey(sa.PrivateKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse GCP private key: %v", err)
+		}
+		if sa.TokenURI == "" {
+			sa.TokenURI = "https://oauth2.googleapis.com/token"
+		}
+		return cachedGCPToken(func() (gcpToken, error) {
+			return serviceAccountToken(client, sa, key, scope)
+		}), sa.ProjectID, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	probe := &http.Client{Timeout: 500 * time.Millisecond}
+	project, err := metadataGet(probe, host, "/project/project-id")
+	if err != nil {
+		return nil, "", errNoGCPCredentials
+	}
+	return cachedGCPToken(func() (gcpToken, error) {
+		body, err := metadataGet(client, host, "/instance/service-accounts/default/token")
+		if err != nil {
+			return gcpToken{}, err
+		}
+		var tok gcpToken
+		err = json.Unmarshal([]byte(body), &tok)
+		return tok, err
+	}), project, nil
+}
+
+func cachedGCPToken(fetch func() (gcpToken, error)) GCPTokenSource {
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if token != "" && time.Until(expires) > time.Minute {
+			return token, nil
+		}
+		tok, err := fetch()
+		if err != nil {
+			return "", err
+		}
+		token, expires = tok.AccessToken, time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second)
+		return token, nil
+	}
+}
+
+func metadataGet(client *http.Client, host, path string) (string, error) {
+	req, _ := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1"+path, nil)
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("metadata server: " + resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// serviceAccountToken exchanges a signed JWT for an access token
+func serviceAccountToken(client *http.Client, sa gcpServiceAccount, key *rsa.PrivateKey, scope string) (gcpToken, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": scope,
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return gcpToken{}, err
+	}
+
+	resp, err := client.PostForm(sa.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return gcpToken{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return gcpToken{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return gcpToken{}, fmt.Errorf("GCP token exchange: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var tok gcpToken
+	err = json.Unmarshal(body, &tok)
+	return tok, err
+}
+
+func parseRSAKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA")
+	}
+	return key, nil
+}
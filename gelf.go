@@ -0,0 +1,204 @@
+package hybridlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GELF compression for UDP messages, TCP messages are never compressed
+type GelfCompression int
+
+const (
+	GelfGzip GelfCompression = iota
+	GelfZlib
+	GelfNoCompression
+)
+
+const (
+	gelfDefaultChunkSize = 1420 // fits a WAN MTU
+	gelfMaxChunks        = 128
+)
+
+// GelfOptions configures the GELF (Graylog) output
+type GelfOptions struct {
+	Network     string          // "udp" (default) or "tcp"
+	Address     string          // Graylog input host:port
+	Host        string          // GELF host field, defaults to the hostname
+	Compression GelfCompression // UDP compression, gzip by default
+	ChunkSize   int             // max UDP datagram size, defaults to 1420
+}
+
+// gelfWriter sends entries as GELF 1.1 messages, chunked over UDP or null
+// byte delimited over TCP
+type gelfWriter struct {
+	mu     sync.Mutex
+	opts   GelfOptions
+	conn   net.Conn
+	redial redialer // backoff between reconnects, like networkWriter
+}
+
+func newGelfWriter(opts GelfOptions) *gelfWriter {
+	if opts.Network == "" {
+		opts.Network = "udp"
+	}
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	if opts.ChunkSize <= 12 {
+		opts.ChunkSize = gelfDefaultChunkSize
+	}
+	return &gelfWriter{opts: opts}
+}
+
+// Write sends preformatted bytes as the short message of an info entry
+func (g *gelfWriter) Write(p []byte) (int, error) {
+	e := &logrus.Entry{Time: time.Now(), Level: logrus.InfoLevel, Message: string(bytes.TrimRight(p, "\n"))}
+	return len(p), g.WriteEntry(e)
+}
+
+// WriteEntry sends the entry with its fields as GELF additional fields
+func (g *gelfWriter) WriteEntry(e *logrus.Entry) error {
+	msg, err := json.Marshal(g.message(e))
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// One retry with a fresh connection, the server may have restarted
+	for attempt := 0; attempt < 2; attempt++ {
+		if g.conn == nil {
+			if err = g.redial.ready(g.opts.Network, g.opts.Address); err != nil {
+				return err
+			}
+			if g.conn, err = net.DialTimeout(g.opts.Network, g.opts.Address, networkWriteTimeout); err != nil {
+				g.redial.fail()
+				return err
+			}
+		}
+		g.conn.SetWriteDeadline(time.Now().Add(networkWriteTimeout))
+		if g.opts.Network == "tcp" {
+			_, err = g.conn.Write(append(msg, 0))
+		} else {
+			err = g.writeUDP(msg)
+		}
+		if err == nil {
+			g.redial.succeeded()
+			return nil
+		}
+		g.conn.Close()
+		g.conn = nil
+		if isTimeout(err) {
+			break // stalled rather than restarted
+		}
+	}
+	g.redial.fail()
+	return err
+}
+
+func (g *gelfWriter) message(e *logrus.Entry) map[string]interface{} {
+	short, full := e.Message, ""
+	if i := strings.IndexByte(short, '\n'); i >= 0 {
+		short, full = short[:i], short
+	}
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          g.opts.Host,
+		"short_message": short,
+		"timestamp":     float64(e.Time.UnixNano()) / float64(time.Second),
+		"level":         syslogSeverity[e.Level],
+	}
+	if full != "" {
+		m["full_message"] = full
+	}
+	if e.HasCaller() {
+		m["_file"] = e.Caller.File
+		m["_line"] = e.Caller.Line
+		m["_function"] = e.Caller.Function
+	}
+	for k, v := range e.Data {
+		if k == "id" {
+			k = "id_" // _id is reserved by GELF
+		}
+		switch v := v.(type) {
+		case error:
+			m["_"+k] = v.Error()
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			m["_"+k] = v
+		default:
+			m["_"+k] = fmt.Sprint(v)
+		}
+	}
+	return m
+}
+
+func (g *gelfWriter) writeUDP(msg []byte) error {
+	var b bytes.Buffer
+	switch g.opts.Compression {
+	case GelfGzip:
+		zw := gzip.NewWriter(&b)
+		zw.Write(msg)
+		zw.Close()
+		msg = b.Bytes()
+	case GelfZlib:
+		zw := zlib.NewWriter(&b)
+		zw.Write(msg)
+		zw.Close()
+		msg = b.Bytes()
+	}
+
+	if len(msg) <= g.opts.ChunkSize {
+		_, err := g.conn.Write(msg)
+		return err
+	}
+
+	// Chunked: magic bytes, 8 byte message id, sequence number and count
+	payload := g.opts.ChunkSize - 12
+	count := (len(msg) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return errors.New("gelf message too large")
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	chunk := make([]byte, 0, g.opts.ChunkSize)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * payload
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, msg[seq*payload:end]...)
+		if _, err := g.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to Graylog
+func (g *gelfWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
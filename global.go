@@ -0,0 +1,80 @@
+package hybridlog
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	fallbackOnce   sync.Once
+	fallbackLogger *HybridLogger
+)
+
+// std returns the logger of the package-level functions: the one set by
+// SetDefault, or until then one writing to stderr
+func std() *HybridLogger {
+	if h := Default(); h != nil {
+		return h
+	}
+	fallbackOnce.Do(func() {
+		o := DefaultOptions()
+		o.NoFile = true
+		o.ConsoleOutput = true
+		o.ConsoleWriter = os.Stderr
+		h, err := InitWithOptions(o)
+		if err != nil {
+			// Not expected without a file, but the package-level functions
+			// must not panic: log to stderr through logrus alone
+			l := logrus.New()
+			l.SetOutput(os.Stderr)
+			h = &HybridLogger{Logger: l, core: &core{
+				file:   newRotatingFile(o.LogDir, FileOptions{FileName: o.FileName}, rotation{interval: rotationInterval(0), loc: time.Local}),
+				hooks:  logrus.LevelHooks{},
+				named:  map[string]*namedLogger{},
+				noFile: true, // the file is never opened
+			}}
+			h.root = h
+			h.Warnf("hybridlog: default logger: %v", err)
+		}
+		fallbackLogger = h
+	})
+	return fallbackLogger
+}
+
+// Package-level functions logging through the default logger, see SetDefault
+
+func Trace(args ...interface{})                 { std().Logger.Trace(args...) }
+func Tracef(format string, args ...interface{}) { std().Logger.Tracef(format, args...) }
+func Debug(args ...interface{})                 { std().Logger.Debug(args...) }
+func Debugf(format string, args ...interface{}) { std().Logger.Debugf(format, args...) }
+func Info(args ...interface{})                  { std().Logger.Info(args...) }
+func Infof(format string, args ...interface{})  { std().Logger.Infof(format, args...) }
+func Warn(args ...interface{})                  { std().Logger.Warn(args...) }
+func Warnf(format string, args ...interface{})  { std().Logger.Warnf(format, args...) }
+func Error(args ...interface{})                 { std().Logger.Error(args...) }
+func Errorf(format string, args ...interface{}) { std().Logger.Errorf(format, args...) }
+func Fatal(args ...interface{})                 { std().Logger.Fatal(args...) }
+func Fatalf(format string, args ...interface{}) { std().Logger.Fatalf(format, args...) }
+func Panic(args ...interface{})                 { std().Logger.Panic(args...) }
+func Panicf(format string, args ...interface{}) { std().Logger.Panicf(format, args...) }
+
+func Traceln(args ...interface{}) { std().Logger.Traceln(args...) }
+func Debugln(args ...interface{}) { std().Logger.Debugln(args...) }
+func Infoln(args ...interface{})  { std().Logger.Infoln(args...) }
+func Warnln(args ...interface{})  { std().Logger.Warnln(args...) }
+func Errorln(args ...interface{}) { std().Logger.Errorln(args...) }
+func Fatalln(args ...interface{}) { std().Logger.Fatalln(args...) }
+func Panicln(args ...interface{}) { std().Logger.Panicln(args...) }
+
+func WithField(key string, value interface{}) *Entry { return std().WithField(key, value) }
+func WithFields(fields logrus.Fields) *Entry         { return std().WithFields(fields) }
+func WithError(err error) *Entry                     { return std().WithError(err) }
+func WithContext(ctx context.Context) *Entry         { return std().WithContext(ctx) }
+
+func IsLevelEnabled(level int) bool { return std().IsLevelEnabled(level) }
+func IsDebugEnabled() bool          { return std().IsDebugEnabled() }
+func IsTraceEnabled() bool          { return std().IsTraceEnabled() }
@@ -4,7 +4,6 @@ go 1.24.4
 
 require (
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/sys v0.37.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
-
-require golang.org/x/sys v0.37.0 // indirect
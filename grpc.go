@@ -0,0 +1,57 @@
+package hybridlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The server interceptors calling LogGRPCCall and the grpclog.LoggerV2
+// adapter are in the hybridloggrpc module, which keeps grpc out of this
+// package's dependencies
+
+// GRPCCall describes a finished gRPC call, see LogGRPCCall
+type GRPCCall struct {
+	Method    string // full method name, e.g. /pkg.Service/Method
+	Peer      string // remote address
+	Duration  time.Duration
+	Err       error  // the handler's error
+	RequestID string // omitted if empty
+
+	// Code is the name of the call's status code, e.g. NotFound, and Level
+	// the level it is logged at. hybridloggrpc sets both from the status of
+	// Err, left empty the call is logged as OK at Info, or as Unknown at
+	// Error when Err is set
+	Code  string
+	Level logrus.Level
+}
+
+// LogGRPCCall logs an access entry for call at the level of its status
+// code. Context fields of ctx are added as for WithContext
+func (h *HybridLogger) LogGRPCCall(ctx context.Context, call GRPCCall) {
+	code, level := call.Code, call.Level
+	switch {
+	case code != "":
+	case call.Err == nil:
+		code, level = "OK", logrus.InfoLevel
+	default:
+		code, level = "Unknown", logrus.ErrorLevel
+	}
+	fields := logrus.Fields{
+		"grpc.method": call.Method,
+		"grpc.code":   code,
+		"duration_ms": float64(call.Duration.Microseconds()) / 1000,
+	}
+	if call.Peer != "" {
+		fields["peer"] = call.Peer
+	}
+	if call.RequestID != "" {
+		fields["request_id"] = call.RequestID
+	}
+	e := h.Logger.WithContext(ctx).WithFields(fields)
+	if call.Err != nil {
+		e = e.WithError(call.Err)
+	}
+	e.Log(level, "finished call "+call.Method)
+}
@@ -0,0 +1,56 @@
+package hybridlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Healthy returns an error if the logger can't currently write, for use in
+// readiness probes: the log directory isn't writable, a file is closed or was
+// removed, the last write to a file failed or the async queue is full
+func (h *HybridLogger) Healthy() error {
+	if h.async != nil && len(h.async.ch) >= cap(h.async.ch) {
+		return fmt.Errorf("async log queue is full (%d entries)", cap(h.async.ch))
+	}
+	for _, f := range h.files() {
+		if f == h.file && h.noFile {
+			continue
+		}
+		if err := f.healthy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healthy checks the file and that its directory accepts new files
+func (f *rotatingFile) healthy() error {
+	f.mu.Lock()
+	closed, opened, writeErr := f.closed, f.opened, f.writeErr
+	name := f.lumber.Filename
+	f.mu.Unlock()
+
+	if closed {
+		return fmt.Errorf("log file %s is closed", name)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("last write to %s failed: %v", name, writeErr)
+	}
+	if opened {
+		if _, err := os.Stat(name); err != nil {
+			return fmt.Errorf("log file %s is gone: %v", name, err)
+		}
+	}
+
+	dir := filepath.Dir(name)
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !opened {
+		dir = f.logDir // the period's directory is created on the first write
+	}
+	probe, err := os.CreateTemp(dir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("log directory %s is not writable: %v", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
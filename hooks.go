@@ -0,0 +1,36 @@
+package hybridlog
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// userHook wraps a hook added with AddHook so it skips dropped entries
+type userHook struct {
+	logrus.Hook
+}
+
+func (u *userHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	return u.Hook.Fire(e)
+}
+
+// AddHook adds a hook fired for the entries of every logger sharing the
+// files of h (the root, named and child loggers), for the levels it returns.
+// Hooks run in the order added and after the built-in ones, so they see the
+// caller, context and global fields and redacted values. Entries dropped by
+// sampling, rate limiting, filters or dedup don't reach them. A hook
+// returning an error doesn't stop the hooks after it, logrus reports the
+// error on stderr
+func (h *HybridLogger) AddHook(hook logrus.Hook) {
+	h.hookMu.Lock()
+	defer h.hookMu.Unlock()
+
+	hooks := make(logrus.LevelHooks, len(h.hooks))
+	for lvl, hs := range h.hooks {
+		hooks[lvl] = append([]logrus.Hook(nil), hs...) // entries may be firing the old ones
+	}
+	hooks.Add(&userHook{hook})
+	h.hooks = hooks
+}
@@ -0,0 +1,198 @@
+package hybridlog
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HTTPAccessOptions configures HTTPMiddleware
+type HTTPAccessOptions struct {
+	RouteLevels       map[string]logrus.Level // level of the entries of requests under a path prefix that didn't fail with 5xx, the longest prefix wins
+	MaxBodyBytes      int64                   // cap request bodies, larger ones get a 413 or fail to read with *http.MaxBytesError, 0 for no limit
+	TrustForwardedFor bool                    // take the remote IP from X-Forwarded-For, behind a proxy
+	RequestIDHeader   string                  // header of incoming correlation IDs, defaults to RequestIDHeader
+	NoRequestID       bool                    // don't give requests correlation IDs
+
+	File   *FileOptions // write access entries to this file as text lines instead of to the application log, named <name>-access<ext> by default
+	Format string       // line format of File in Apache's LogFormat syntax, defaults to CombinedLogFormat
+}
+
+// HTTPMiddleware logs an access entry for every request handled by next:
+// method, path, status, bytes, duration_ms, remote_ip and user_agent. Entries
+// are Error for 5xx responses, Warn for 4xx and Info otherwise unless the
+// route has a level in HTTPAccessOptions.RouteLevels. Each request gets a
+// correlation ID, the one in its X-Request-ID header or a new one, which is
+// echoed in the response and logged with every entry of the request's
+// context, see ContextWithRequestID
+func (h *HybridLogger) HTTPMiddleware(next http.Handler) http.Handler {
+	opts := h.httpAccess
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		if !opts.NoRequestID {
+			header := opts.RequestIDHeader
+			if header == "" {
+				header = RequestIDHeader
+			}
+			id := r.Header.Get(header)
+			if !ValidRequestID(id) {
+				id = NewRequestID()
+			}
+			w.Header().Set(header, id)
+			r = r.WithContext(ContextWithRequestID(r.Context(), id))
+		}
+		if opts.MaxBodyBytes > 0 && r.ContentLength > opts.MaxBodyBytes {
+			http.Error(rec, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		} else {
+			if opts.MaxBodyBytes > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(rec, r.Body, opts.MaxBodyBytes)
+			}
+			next.ServeHTTP(rec, r)
+		}
+		h.LogHTTPAccess(r, rec.status, rec.bytes, time.Since(start))
+	})
+}
+
+// LogHTTPAccess logs the access entry of HTTPMiddleware for a finished
+// request, for frameworks with their own middleware type. With Gin:
+//
+//	r.Use(func(c *gin.Context) {
+//		start := time.Now()
+//		c.Next()
+//		h.LogHTTPAccess(c.Request, c.Writer.Status(), int64(c.Writer.Size()), time.Since(start))
+//	})
+//	gin.DefaultWriter = h.LineWriter(logrus.DebugLevel)
+//	gin.DefaultErrorWriter = h.LineWriter(logrus.ErrorLevel)
+//
+// Echo takes net/http middleware as is:
+//
+//	e.Use(echo.WrapMiddleware(h.HTTPMiddleware), echo.WrapMiddleware(h.HTTPRecovery))
+//	e.Logger.SetOutput(h.LineWriter(logrus.InfoLevel))
+func (h *HybridLogger) LogHTTPAccess(r *http.Request, status int, bytes int64, duration time.Duration) {
+	opts := h.httpAccess
+	if h.accessFile != nil {
+		h.writeAccessLine(&accessRecord{
+			r:        r,
+			status:   status,
+			bytes:    bytes,
+			start:    time.Now().Add(-duration),
+			duration: duration,
+			remoteIP: remoteIP(r, opts.TrustForwardedFor),
+		})
+		return
+	}
+	level := logrus.InfoLevel
+	if status >= 400 {
+		level = logrus.WarnLevel
+	}
+	if status >= 500 {
+		level = logrus.ErrorLevel
+	} else if lvl, ok := routeLevel(opts.RouteLevels, r.URL.Path); ok {
+		level = lvl
+	}
+	h.Logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"method":      r.Method,
+		"path":        r.URL.Path,
+		"status":      status,
+		"bytes":       bytes,
+		"duration_ms": float64(duration.Microseconds()) / 1000,
+		"remote_ip":   remoteIP(r, opts.TrustForwardedFor),
+		"user_agent":  r.UserAgent(),
+	}).Log(level, fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, status))
+}
+
+// HTTPRecovery recovers panics of next, logs them like RecoverAndLog with
+// the request method and path and responds 500. http.ErrAbortHandler is
+// passed on, net/http uses it to abort a response silently. Put it inside
+// HTTPMiddleware so the 500 is access logged
+func (h *HybridLogger) HTTPRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			h.Child(logrus.Fields{"method": r.Method, "path": r.URL.Path}).logPanic(v, debug.Stack())
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeLevel returns the level of the longest prefix of path in levels
+func routeLevel(levels map[string]logrus.Level, path string) (logrus.Level, bool) {
+	var level logrus.Level
+	best := -1
+	for prefix, lvl := range levels {
+		if len(prefix) > best && strings.HasPrefix(path, prefix) {
+			best, level = len(prefix), lvl
+		}
+	}
+	return level, best >= 0
+}
+
+// remoteIP returns the client address of r without its port
+func remoteIP(r *http.Request, forwarded bool) string {
+	if forwarded {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			ip, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// statusRecorder captures the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status, s.wroteHeader = code, true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Flush and Hijack pass through so streaming and websockets keep working
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer doesn't support hijacking")
+	}
+	return hj.Hijack()
+}
+
+// Unwrap gives http.ResponseController access to the original writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -1,24 +1,97 @@
 package hybridlog
 
 import (
+	"crypto/aes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type HybridLogger struct {
 	*logrus.Logger
-	mu          sync.Mutex
-	lumber      *lumberjack.Logger
-	logDir      string
-	fileName    string
-	currentDate string
-	timeFormat  string
+	*core
+	fields logrus.Fields // stamped on every entry of a derived logger
+	gate   *levelGate    // nil unless a ring buffer keeps entries below the level
+}
+
+// core is the state shared by a HybridLogger and the named loggers derived
+// from it: the rotating file, the outputs, hooks and fields
+type core struct {
+	file      *rotatingFile
+	fileOut   io.Writer // file, or a fallbackWriter around it, buffered if enabled
+	buffers   []*bufferedWriter
+	errorFile *rotatingFile  // nil unless warnings and errors go to their own file
+	async     *asyncWriter   // nil unless async mode is enabled
+	drops     *dropReporter  // nil unless async writes drop entries
+	ring      *ringBuffer    // nil unless recent entries are kept for crash dumps
+	archiver  *archiver      // nil unless completed files are uploaded
+	diskWatch *diskWatch     // nil unless free space is watched
+	retention *retention     // nil unless the total size is capped or a RetentionPolicy is set
+	bundler   *bundler       // nil unless past periods are bundled
+	syncer    *syncer        // nil unless files are fsynced periodically
+	watcher   *configWatcher // nil unless the config file is watched
+	sampler   *samplingHook  // nil unless sampling is configured
+	limiter   *rateLimitHook // nil unless rate limiting is configured
+	dedup     *dedupHook     // nil unless consecutive duplicates are collapsed
+	noFile    bool           // the main file is replaced by another output
+	fileLevel atomic.Uint32  // most verbose level written to the main file, see SetSinkLevel
+
+	httpAccess   HTTPAccessOptions // see HTTPMiddleware
+	accessFile   *rotatingFile     // nil unless access entries have their own file
+	accessOut    io.Writer
+	accessFormat []accessPart
+	auditFile    *rotatingFile // nil unless Audit is enabled
+	auditFields  []string
+
+	mu    sync.Mutex
+	sigCh chan os.Signal
+
+	configMu   sync.Mutex
+	fromConfig configSettings // the settings the last ApplyConfig set
+
+	outMu     sync.RWMutex
+	formatter logrus.Formatter // file formatter
+	outputs   []*output        // secondary outputs such as the console
+
+	formatOpts formatOptions // used by SetFormat
+
+	hookMu sync.RWMutex
+	hooks  logrus.LevelHooks // hooks fired for the root and every derived logger
+
+	ctxMu      sync.RWMutex
+	extractors []ContextExtractor
+
+	fieldsMu      sync.RWMutex
+	globalFields  logrus.Fields
+	dynamicFields map[string]func() interface{} // copied on write, entries may be reading the old one
+
+	filterMu sync.RWMutex
+	filters  []Filter
+
+	redactMu sync.RWMutex
+	redactor *redactor // nil unless values are masked
+
+	rotateMu sync.RWMutex
+	onRotate []func(oldPath, newPath string)
+
+	errMu     sync.RWMutex
+	onError   func(error) // see OnError
+	errCount  atomic.Uint64
+	lastErr   error // the last error reported, see Stats
+	lastErrAt time.Time
+
+	metrics metrics // see MetricsHandler
+
+	root    *HybridLogger // the logger returned by Init
+	namedMu sync.Mutex
+	named   map[string]*namedLogger
 }
 
 // Level mapping for int → logrus.Level
@@ -41,108 +114,548 @@ var levelMap = map[int]logrus.Level{
 // level: log level uint, 6:Trace, 5:Debug, 4:Info, 3:Warn, 2:Error, 1:Fatal, 0:Panic
 // compress: whether to compress log files
 func Init(logDir, logFileName string, maxSizeMB, maxBackups, maxAgeDays int, logLevel int, compress bool) (logObj *HybridLogger, err error) {
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		err = fmt.Errorf("failed to create log dir: %v", err)
-		return nil, err
-	}
-	timeFormat := "2006-01-02"
-	// Get current date in YYYY-MM-DD format
-	currentDate := time.Now().Format(timeFormat)
-	ext := filepath.Ext(logFileName)
-	nameWithoutExt := logFileName[:len(logFileName)-len(ext)]
-
-	lumber := &lumberjack.Logger{
-		Filename:   filepath.Join(logDir, fmt.Sprintf("%s-%s%s", nameWithoutExt, currentDate, ext)),
-		MaxSize:    maxSizeMB,
+	return InitWithOptions(Options{
+		LogDir:     logDir,
+		FileName:   logFileName,
+		MaxSizeMB:  maxSizeMB,
 		MaxBackups: maxBackups,
-		MaxAge:     maxAgeDays,
+		MaxAgeDays: maxAgeDays,
+		Level:      logLevel,
 		Compress:   compress,
+	})
+}
+
+// New initializes the logger from DefaultOptions with the given options applied
+func New(opts ...Option) (*HybridLogger, error) {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return InitWithOptions(o)
+}
+
+// InitWithOptions initializes the logger from an Options struct
+func InitWithOptions(opts Options) (logObj *HybridLogger, err error) {
+	logDir := opts.LogDir
+	logFileName := opts.FileName
+	gid, err := lookupGroup(opts.FileGroup)
+	if err != nil {
+		return nil, err
+	}
+	perm := filePerm{dirMode: opts.DirMode, fileMode: opts.FileMode, gid: gid}
+	if opts.SharedFile && opts.HashChain {
+		return nil, fmt.Errorf("HashChain can't be used with SharedFile")
+	}
+	if opts.EncryptionKeys != nil {
+		_, key, err := opts.EncryptionKeys.CurrentKey()
+		if err == nil {
+			_, err = aes.NewCipher(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %v", err)
+		}
+	}
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+	namer, err := newFileNamer(opts.FileNameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	mainName, err := namer.name(logFileName)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoFile || opts.ErrorFile != nil || opts.HTTPAccess != nil && opts.HTTPAccess.File != nil || opts.Audit != nil {
+		if err := perm.mkdirAll(logDir); err != nil {
+			return nil, fmt.Errorf("%w: failed to create log dir: %v", ErrUnwritableDir, err)
+		}
+		if err := checkWritable(logDir); err != nil {
+			return nil, err
+		}
+	}
+	interval := rotationInterval(opts.RotationInterval)
+	timeFormat := periodFormat(interval)
+	if opts.DatePattern != "" {
+		if err := validateDatePattern(opts.DatePattern, interval); err != nil {
+			return nil, err
+		}
+		timeFormat = opts.DatePattern
+	}
+	if opts.DirPattern != "" {
+		if opts.DatePattern != "" {
+			return nil, fmt.Errorf("DatePattern and DirPattern can't be used together")
+		}
+		if err := validateDatePattern(opts.DirPattern, interval); err != nil {
+			return nil, err
+		}
+		timeFormat = opts.DirPattern
+	}
+	level := opts.Level
+	if opts.LevelName != "" {
+		if level, err = ParseLevel(opts.LevelName); err != nil {
+			return nil, err
+		}
+	}
+	formatOpts := formatOptions{timestampFormat: opts.TimestampFormat, fieldKeys: opts.FieldKeys, prettyPrint: opts.PrettyJSON}
+	formatter := opts.Formatter
+	if opts.Engine != nil {
+		formatter = &engineFormatter{engine: opts.Engine}
+	}
+	if formatter == nil {
+		if formatter, err = newFormatter(opts.Format, formatOpts); err != nil {
+			return nil, err
+		}
+	}
+	rotationLoc := opts.RotationLocation
+	if rotationLoc == nil {
+		rotationLoc = time.Local
+	}
+	r := rotation{interval: interval, timeFormat: timeFormat, inDir: opts.DirPattern != "", loc: rotationLoc}
+	if opts.RotateAt != "" {
+		if r.at, err = parseRotateAt(opts.RotateAt); err != nil {
+			return nil, err
+		}
 	}
 
 	h := &HybridLogger{
-		Logger:      logrus.New(),
-		lumber:      lumber,
-		logDir:      logDir,
-		fileName:    logFileName,
-		currentDate: currentDate,
-		timeFormat:  timeFormat,
+		Logger: logrus.New(),
+		core: &core{
+			file: newRotatingFile(logDir, FileOptions{
+				FileName:   mainName,
+				MaxSizeMB:  opts.MaxSizeMB,
+				MaxBackups: opts.MaxBackups,
+				MaxAgeDays: opts.MaxAgeDays,
+				Compress:   opts.Compress,
+			}, r),
+			hooks:      logrus.LevelHooks{},
+			extractors: append([]ContextExtractor(nil), opts.ContextExtractors...),
+			filters:    append([]Filter(nil), opts.Filters...),
+			named:      map[string]*namedLogger{},
+			formatOpts: formatOpts,
+		},
+	}
+	h.root = h
+	h.fileOut = h.fileWriter(h.file, opts)
+	h.fileLevel.Store(uint32(logrus.TraceLevel))
+	h.noFile = opts.NoFile
+
+	if opts.ConsoleOutput {
+		console := opts.ConsoleWriter
+		if console == nil {
+			console = os.Stdout
+		}
+		consoleFormatter := opts.ConsoleFormatter
+		if consoleFormatter == nil {
+			consoleFormatter = ConsoleFormatter(console)
+		}
+		h.addOutput(&output{name: "console", w: console, formatter: consoleFormatter, level: logrus.TraceLevel})
+	}
+
+	if opts.ErrorFile != nil {
+		errOpts := *opts.ErrorFile
+		if errOpts.FileName == "" {
+			ext := filepath.Ext(logFileName)
+			errOpts.FileName = logFileName[:len(logFileName)-len(ext)] + "-error" + ext
+		}
+		if errOpts.FileName, err = namer.name(errOpts.FileName); err != nil {
+			return nil, err
+		}
+		h.errorFile = newRotatingFile(logDir, errOpts, r)
+		h.addOutput(&output{name: "error_file", w: h.fileWriter(h.errorFile, opts), level: logrus.WarnLevel})
+	}
+
+	if opts.HTTPAccess != nil && opts.HTTPAccess.File != nil {
+		format := opts.HTTPAccess.Format
+		if format == "" {
+			format = CombinedLogFormat
+		}
+		if h.accessFormat, err = parseAccessFormat(format); err != nil {
+			return nil, err
+		}
+		accessOpts := *opts.HTTPAccess.File
+		if accessOpts.FileName == "" {
+			ext := filepath.Ext(logFileName)
+			accessOpts.FileName = logFileName[:len(logFileName)-len(ext)] + "-access" + ext
+		}
+		if accessOpts.FileName, err = namer.name(accessOpts.FileName); err != nil {
+			return nil, err
+		}
+		h.accessFile = newRotatingFile(logDir, accessOpts, r)
+		h.accessOut = h.fileWriter(h.accessFile, opts)
+	}
+
+	if opts.Audit != nil {
+		auditOpts := opts.Audit.File
+		if auditOpts.FileName == "" {
+			ext := filepath.Ext(logFileName)
+			auditOpts.FileName = logFileName[:len(logFileName)-len(ext)] + "-audit" + ext
+		}
+		if auditOpts.FileName, err = namer.name(auditOpts.FileName); err != nil {
+			return nil, err
+		}
+		h.auditFile = newRotatingFile(logDir, auditOpts, r)
+		h.auditFields = opts.Audit.RequiredFields
+		if h.auditFields == nil {
+			h.auditFields = defaultAuditFields
+		}
+	}
+
+	if opts.Syslog != nil {
+		h.addOutput(&output{name: "syslog", w: newSyslogWriter(*opts.Syslog), formatter: opts.Syslog.Formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Journald || opts.JournaldOnly {
+		journald, err := newJournaldWriter(opts.JournaldIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		h.addOutput(&output{name: "journald", w: journald, level: logrus.TraceLevel, owned: true})
+		h.noFile = h.noFile || opts.JournaldOnly
+	}
+
+	if opts.Gelf != nil {
+		h.addOutput(&output{name: "gelf", w: newGelfWriter(*opts.Gelf), level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Network != nil {
+		h.addOutput(&output{name: "network", w: newNetworkWriter(*opts.Network), formatter: opts.Network.Formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Loki != nil {
+		h.addOutput(&output{name: "loki", w: newLokiWriter(*opts.Loki), formatter: opts.Loki.Formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Elasticsearch != nil {
+		es, formatter := newElasticsearchWriter(*opts.Elasticsearch)
+		h.addOutput(&output{name: "elasticsearch", w: es, formatter: formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Fluentd != nil {
+		h.addOutput(&output{name: "fluentd", w: newFluentdWriter(*opts.Fluentd), level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.CloudWatch != nil {
+		h.addOutput(&output{name: "cloudwatch", w: newCloudWatchWriter(*opts.CloudWatch), formatter: opts.CloudWatch.Formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.CloudLogging != nil {
+		cl, err := newCloudLoggingWriter(*opts.CloudLogging)
+		if err != nil && !errors.Is(err, errNoGCPCredentials) {
+			return nil, err
+		}
+		if cl != nil { // file-only when not running with GCP credentials
+			h.addOutput(&output{name: "cloud_logging", w: cl, formatter: opts.CloudLogging.Formatter, level: logrus.TraceLevel, owned: true})
+		}
+	}
+
+	if opts.Splunk != nil {
+		h.addOutput(&output{name: "splunk", w: newSplunkWriter(*opts.Splunk), formatter: opts.Splunk.Formatter, level: logrus.TraceLevel, owned: true})
+	}
+
+	if opts.Webhook != nil {
+		h.addOutput(&output{name: "webhook", w: newWebhookWriter(*opts.Webhook), level: logrus.ErrorLevel, owned: true})
+	}
+
+	if opts.OTLP != nil {
+		h.addOutput(&output{name: "otlp", w: newOTLPWriter(*opts.OTLP), level: logrus.TraceLevel, owned: true})
+	}
+
+	for name, config := range opts.Sinks {
+		s, err := newSink(name, config)
+		if err != nil {
+			h.closeOutputs()
+			return nil, err
+		}
+		h.AddSink(name, s)
+	}
+
+	for name, level := range opts.SinkLevels {
+		if err := h.SetSinkLevel(name, level); err != nil {
+			h.closeOutputs()
+			return nil, err
+		}
+	}
+
+	if opts.Archive != nil && opts.Archive.Store != nil {
+		h.archiver = newArchiver(h.core, *opts.Archive)
+	}
+	if opts.Bundle {
+		h.bundler = newBundler(h.core)
+	}
+	// Lumberjack's cleanup doesn't run for shared files, it never rotates them
+	allDates := opts.RetainAcrossDates || opts.SharedFile
+	if opts.MaxTotalSizeMB > 0 || allDates || opts.RetentionPolicy != nil {
+		h.retention = newRetention(h.core, opts.MaxTotalSizeMB, allDates, opts.RetentionPolicy)
+	}
+	policy := opts.RotationPolicy
+	if opts.MaxLines > 0 {
+		policy = LinePolicy(int64(opts.MaxLines))
+		if opts.RotationPolicy != nil {
+			policy = AnyPolicy(opts.RotationPolicy, policy)
+		}
+	}
+	for _, f := range h.files() {
+		f.rotated = h.fileRotated
+		f.report = h.reportError
+		f.perm = perm
+		f.policy = policy
+		f.countLines = opts.MaxLines > 0
+		if opts.EncryptionKeys != nil {
+			f.encrypt = newEncrypter(opts.EncryptionKeys)
+		}
+		if opts.HashChain {
+			f.chain = newHashChain(opts.HashChainKey)
+		}
+		if opts.SharedFile {
+			f.shared = newSharedFile(f.logDir, undatedName(f.fileName), perm.fileMode)
+			f.shared.sync = opts.SyncEvery > 0 || opts.SyncInterval > 0
+		}
+		f.syncEvery, f.syncInterval = opts.SyncEvery, opts.SyncInterval > 0
+		if opts.LatestLink {
+			f.link = filepath.Join(logDir, undatedName(f.fileName))
+		}
+	}
+	if opts.SyncInterval > 0 {
+		h.syncer = newSyncer(h.core, opts.SyncInterval)
+	}
+
+	if opts.DiskWatch != nil && opts.DiskWatch.MinFreeMB > 0 {
+		h.diskWatch = newDiskWatch(h.core, logDir, *opts.DiskWatch)
+	}
+
+	if opts.HTTPAccess != nil {
+		h.httpAccess = *opts.HTTPAccess
+	}
+
+	if opts.Async {
+		h.async = newAsyncWriter(opts.AsyncBufferSize, opts.AsyncFlushInterval, opts.AsyncDropWhenFull, h.writeFile)
 	}
 
 	h.Logger.SetOutput(h)
-	h.SetLogLevel(logLevel) // Set initial level
-	h.Logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
+	h.SetGlobalFields(opts.GlobalFields)
+	for name, fn := range opts.DynamicFields {
+		h.AddDynamicField(name, fn)
+	}
+	h.Logger.SetReportCaller(opts.ReportCaller)
+	if opts.RingBuffer > 0 {
+		h.ring = newRingBuffer(opts.RingBuffer)
+		h.gate = &levelGate{c: h.core}
+		h.Logger.AddHook(h.gate) // before sharedHooks
+	}
+	h.Logger.AddHook(&sharedHooks{c: h.core})
+	if len(opts.Sampling) > 0 {
+		h.sampler = newSamplingHook(opts.Sampling)
+		h.hooks.Add(h.sampler)
+	}
+	if opts.RateLimit != nil && opts.RateLimit.PerSecond > 0 {
+		h.limiter = newRateLimitHook(*opts.RateLimit)
+		h.hooks.Add(h.limiter)
+		go h.limiter.run(h.Logger)
+	}
+	h.hooks.Add(&callerHook{skip: opts.CallerSkip})
+	if opts.StackTraces != nil {
+		h.hooks.Add(newStackHook(*opts.StackTraces))
+	}
+	if opts.TimestampLocation != nil {
+		h.hooks.Add(&timezoneHook{loc: opts.TimestampLocation})
+	}
+	// Context fields are added before global ones so they take precedence
+	h.hooks.Add(&contextHook{c: h.core})
+	h.hooks.Add(&globalFieldsHook{c: h.core})
+	h.hooks.Add(&filterHook{c: h.core})
+	h.hooks.Add(&lazyHook{})
+	if opts.ErrorEncoder != nil {
+		h.hooks.Add(&errorHook{encode: opts.ErrorEncoder})
+	}
+	if opts.Redact != nil {
+		h.Redact(*opts.Redact)
+	}
+	h.hooks.Add(&redactHook{c: h.core})
+	if opts.MaxMessageBytes > 0 || opts.MaxFieldBytes > 0 {
+		h.hooks.Add(&truncateHook{maxMessage: opts.MaxMessageBytes, maxField: opts.MaxFieldBytes})
+	}
+	if h.ring != nil {
+		// After redaction, dumps don't reveal what the files wouldn't
+		h.hooks.Add(&ringHook{h: h})
+	}
+	if h.async != nil && opts.AsyncDropWhenFull {
+		h.drops = newDropReporter(h.async, h.Logger, opts.AsyncDropSummaryInterval)
+	}
+	if opts.DedupWindow > 0 {
+		// Last, so entries are compared with all their fields
+		h.dedup = newDedupHook(h.Logger, opts.DedupWindow)
+		h.hooks.Add(h.dedup)
+	}
+	h.OnError(opts.OnError)
+	for _, fn := range opts.OnRotate {
+		h.OnRotate(fn)
+	}
+	for _, hook := range opts.Hooks {
+		h.AddHook(hook)
+	}
+	h.Logger.ExitFunc = func(code int) {
+		h.Close() // don't lose queued entries on Fatal
+		os.Exit(code)
+	}
+	h.SetLogLevel(level) // Set initial level
+	h.SetFileFormatter(formatter)
+	h.Logger.SetFormatter(&teeFormatter{h: h})
 
 	return h, nil
 }
 
-// Write sends logs to lumberjack for rotation
+// Write sends logs to lumberjack for rotation.
+// In async mode the entry is queued and written by a background goroutine
 func (h *HybridLogger) Write(p []byte) (n int, err error) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if h.async != nil {
+		return h.async.Write(p)
+	}
+	return h.writeFile(p)
+}
 
-	// Check if date has changed
-	currentDate := time.Now().Format(h.timeFormat)
-	if h.currentDate != currentDate {
-		// Close the current log file
-		if h.lumber != nil {
-			h.lumber.Close()
+// Flush waits for in-flight and queued writes to reach the log files, once
+// it returns every earlier entry is on disk
+func (h *HybridLogger) Flush() error {
+	if h.dedup != nil {
+		h.dedup.Flush()
+	}
+	if h.async != nil {
+		h.async.Flush()
+	}
+	if err := h.flushBuffers(); err != nil {
+		return err
+	}
+	for _, f := range h.files() {
+		if err := f.Sync(); err != nil {
+			return err
 		}
+	}
+	return h.flushOutputs()
+}
 
-		// Create a new log file with updated date
-		ext := filepath.Ext(h.fileName)
-		nameWithoutExt := h.fileName[:len(h.fileName)-len(ext)]
-		h.lumber = &lumberjack.Logger{
-			Filename:   filepath.Join(h.logDir, fmt.Sprintf("%s-%s%s", nameWithoutExt, currentDate, ext)),
-			MaxSize:    h.lumber.MaxSize,
-			MaxBackups: h.lumber.MaxBackups,
-			MaxAge:     h.lumber.MaxAge,
-			Compress:   h.lumber.Compress,
-		}
-		h.currentDate = currentDate
+// Sync is Flush under the name zap expects, h is a zapcore.WriteSyncer and
+// an io.Writer zerolog can write to. Loggers of either keep their own
+// encoding and levels and get the rotation and file names of h, e.g.
+//
+//	zerolog.New(h)
+//	zapcore.NewCore(zapcore.NewJSONEncoder(cfg), h, zapcore.InfoLevel)
+//
+// Their entries skip the hooks, outputs and level of h
+func (h *HybridLogger) Sync() error {
+	return h.Flush()
+}
+
+// Close flushes and closes the log files, later writes fail with os.ErrClosed
+func (h *HybridLogger) Close() error {
+	h.DisableSignalRotation()
+	h.mu.Lock()
+	watcher := h.watcher
+	h.mu.Unlock()
+	if watcher != nil {
+		watcher.Close()
+	}
+	if h.limiter != nil {
+		h.limiter.Close() // its last summary still goes to the files
+	}
+	if h.dedup != nil {
+		h.dedup.Flush()
+	}
+	if h.bundler != nil {
+		h.bundler.Close()
+	}
+	if h.archiver != nil {
+		h.archiver.Close()
+	}
+	if h.diskWatch != nil {
+		h.diskWatch.Close()
+	}
+	if h.retention != nil {
+		h.retention.Close()
+	}
+	if h.syncer != nil {
+		h.syncer.Close()
+	}
+	if h.drops != nil {
+		h.drops.Close() // its last summary still goes to the queue
+	}
+	if h.async != nil {
+		h.async.Close()
 	}
 
-	return h.lumber.Write(p)
+	var firstErr error
+	for _, b := range h.buffers {
+		if err := b.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, f := range h.files() {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := h.closeOutputs(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
 }
 
-// SetLogLevel changes log level at runtime (using int)
+// SetLogLevel changes log level at runtime (using int). On the root logger
+// it also applies to named loggers without a level of their own
 func (h *HybridLogger) SetLogLevel(level int) {
-	if lvl, ok := levelMap[level]; ok {
-		h.Logger.SetLevel(lvl)
-	} else {
-		h.Logger.SetLevel(logrus.InfoLevel) // default
+	lvl, ok := levelMap[level]
+	if !ok {
+		lvl = logrus.InfoLevel // default
+	}
+	h.setLevel(lvl)
+	if h == h.root {
+		h.syncNamedLevels(lvl)
 	}
 }
 
 // --------- Wrapper Functions ---------
 
+func (h *HybridLogger) Trace(args ...interface{}) { h.Logger.Trace(args...) }
+func (h *HybridLogger) Tracef(format string, args ...interface{}) {
+	h.Logger.Tracef(format, args...)
+}
+func (h *HybridLogger) Traceln(args ...interface{}) { h.Logger.Traceln(args...) }
+
 func (h *HybridLogger) Info(args ...interface{}) { h.Logger.Info(args...) }
 func (h *HybridLogger) Infof(format string, args ...interface{}) {
 	h.Logger.Infof(format, args...)
 }
+func (h *HybridLogger) Infoln(args ...interface{}) { h.Logger.Infoln(args...) }
 
 func (h *HybridLogger) Debug(args ...interface{}) { h.Logger.Debug(args...) }
 func (h *HybridLogger) Debugf(format string, args ...interface{}) {
 	h.Logger.Debugf(format, args...)
 }
+func (h *HybridLogger) Debugln(args ...interface{}) { h.Logger.Debugln(args...) }
 
 func (h *HybridLogger) Warn(args ...interface{}) { h.Logger.Warn(args...) }
 func (h *HybridLogger) Warnf(format string, args ...interface{}) {
 	h.Logger.Warnf(format, args...)
 }
+func (h *HybridLogger) Warnln(args ...interface{}) { h.Logger.Warnln(args...) }
 
 func (h *HybridLogger) Error(args ...interface{}) { h.Logger.Error(args...) }
 func (h *HybridLogger) Errorf(format string, args ...interface{}) {
 	h.Logger.Errorf(format, args...)
 }
+func (h *HybridLogger) Errorln(args ...interface{}) { h.Logger.Errorln(args...) }
 
 func (h *HybridLogger) Fatal(args ...interface{}) { h.Logger.Fatal(args...) }
 func (h *HybridLogger) Fatalf(format string, args ...interface{}) {
 	h.Logger.Fatalf(format, args...)
 }
+func (h *HybridLogger) Fatalln(args ...interface{}) { h.Logger.Fatalln(args...) }
 
 func (h *HybridLogger) Panic(args ...interface{}) { h.Logger.Panic(args...) }
 func (h *HybridLogger) Panicf(format string, args ...interface{}) {
 	h.Logger.Panicf(format, args...)
 }
+func (h *HybridLogger) Panicln(args ...interface{}) { h.Logger.Panicln(args...) }
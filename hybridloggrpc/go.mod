@@ -0,0 +1,23 @@
+module github.com/git4rakesh/hybrid_log/hybridloggrpc
+
+go 1.24.4
+
+// The released hybrid_log is required, to build against a checkout use a
+// workspace in the root of the repository, which .gitignore leaves out:
+//
+//	go work init . ./hybridloggrpc
+//	go work edit -replace github.com/git4rakesh/hybrid_log@v0.1.0=.
+require (
+	github.com/git4rakesh/hybrid_log v0.1.0
+	github.com/sirupsen/logrus v1.9.3
+	google.golang.org/grpc v1.67.1
+)
+
+require (
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
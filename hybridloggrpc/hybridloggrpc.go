@@ -0,0 +1,97 @@
+// Package hybridloggrpc has gRPC server interceptors logging an access
+// entry for every call with HybridLogger.LogGRPCCall, and a Logger for
+// grpc's own messages. It is a module of its own so that the logger doesn't
+// depend on grpc:
+//
+//	grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(hybridloggrpc.UnaryServerInterceptor(h)),
+//		grpc.ChainStreamInterceptor(hybridloggrpc.StreamServerInterceptor(h)),
+//	)
+//
+// Each call gets a correlation ID, the one in its x-request-id metadata or a
+// new one, which is logged with every entry of the call's context, see
+// hybridlog.ContextWithRequestID
+package hybridloggrpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor logs each unary call when its handler returns
+func UnaryServerInterceptor(h *hybridlog.HybridLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, call := newCall(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		call.Duration = time.Since(start)
+		setStatus(&call, err)
+		h.LogGRPCCall(ctx, call)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs each streaming call when its handler returns
+func StreamServerInterceptor(h *hybridlog.HybridLogger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, call := newCall(ss.Context(), info.FullMethod)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		call.Duration = time.Since(start)
+		setStatus(&call, err)
+		h.LogGRPCCall(ctx, call)
+		return err
+	}
+}
+
+// newCall starts the description of a call and returns its context with
+// the correlation ID
+func newCall(ctx context.Context, method string) (context.Context, hybridlog.GRPCCall) {
+	call := hybridlog.GRPCCall{Method: method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		call.Peer = p.Addr.String()
+	}
+	if ids := metadata.ValueFromIncomingContext(ctx, strings.ToLower(hybridlog.RequestIDHeader)); len(ids) > 0 {
+		call.RequestID = ids[0]
+	}
+	if !hybridlog.ValidRequestID(call.RequestID) {
+		call.RequestID = hybridlog.NewRequestID()
+	}
+	return hybridlog.ContextWithRequestID(ctx, call.RequestID), call
+}
+
+// setStatus sets the error of call, its status code and the level it is
+// logged at: Info for OK and the client's mistakes such as NotFound, Warn
+// for DeadlineExceeded, Unavailable and the like and Error for Unknown,
+// Internal and the other server faults
+func setStatus(call *hybridlog.GRPCCall, err error) {
+	st, _ := status.FromError(err) // Unknown for errors without a status
+	code := st.Code()
+	call.Err, call.Code = err, code.String()
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.Unauthenticated:
+		call.Level = logrus.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted, codes.FailedPrecondition,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		call.Level = logrus.WarnLevel
+	default:
+		call.Level = logrus.ErrorLevel
+	}
+}
+
+// serverStream passes the handler the context with the correlation ID
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,47 @@
+package hybridloggrpc
+
+import (
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/grpclog"
+)
+
+var _ grpclog.LoggerV2 = (*Logger)(nil)
+
+// Logger writes grpc's own messages to a HybridLogger, install it with
+// grpclog.SetLoggerV2(hybridloggrpc.NewLogger(h, 0))
+type Logger struct {
+	h         *hybridlog.HybridLogger
+	verbosity int
+}
+
+// NewLogger returns a Logger writing through the "grpc" named logger of h,
+// V(l) reports true up to verbosity
+func NewLogger(h *hybridlog.HybridLogger, verbosity int) *Logger {
+	return &Logger{h: h.Named("grpc"), verbosity: verbosity}
+}
+
+func (g *Logger) Info(args ...interface{})      { g.h.Logger.Log(logrus.InfoLevel, args...) }
+func (g *Logger) Infoln(args ...interface{})    { g.h.Logger.Logln(logrus.InfoLevel, args...) }
+func (g *Logger) Warning(args ...interface{})   { g.h.Logger.Log(logrus.WarnLevel, args...) }
+func (g *Logger) Warningln(args ...interface{}) { g.h.Logger.Logln(logrus.WarnLevel, args...) }
+func (g *Logger) Error(args ...interface{})     { g.h.Logger.Log(logrus.ErrorLevel, args...) }
+func (g *Logger) Errorln(args ...interface{})   { g.h.Logger.Logln(logrus.ErrorLevel, args...) }
+func (g *Logger) Fatal(args ...interface{})     { g.h.Logger.Fatal(args...) }
+func (g *Logger) Fatalln(args ...interface{})   { g.h.Logger.Fatalln(args...) }
+
+func (g *Logger) Infof(format string, args ...interface{}) {
+	g.h.Logger.Logf(logrus.InfoLevel, format, args...)
+}
+func (g *Logger) Warningf(format string, args ...interface{}) {
+	g.h.Logger.Logf(logrus.WarnLevel, format, args...)
+}
+func (g *Logger) Errorf(format string, args ...interface{}) {
+	g.h.Logger.Logf(logrus.ErrorLevel, format, args...)
+}
+func (g *Logger) Fatalf(format string, args ...interface{}) {
+	g.h.Logger.Fatalf(format, args...)
+}
+
+// V reports whether verbosity level l is enabled
+func (g *Logger) V(l int) bool { return l <= g.verbosity }
@@ -0,0 +1,26 @@
+module github.com/git4rakesh/hybrid_log/hybridlogprom
+
+go 1.24.4
+
+// The released hybrid_log is required, to build against a checkout use a
+// workspace in the root of the repository, which .gitignore leaves out:
+//
+//	go work init . ./hybridlogprom
+//	go work edit -replace github.com/git4rakesh/hybrid_log@v0.1.0=.
+require (
+	github.com/git4rakesh/hybrid_log v0.1.0
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/sirupsen/logrus v1.9.3 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
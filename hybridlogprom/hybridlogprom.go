@@ -0,0 +1,63 @@
+// Package hybridlogprom exports the counters of a HybridLogger as a
+// prometheus.Collector, with the names and labels of MetricsHandler. It is a
+// module of its own so that the logger doesn't depend on the Prometheus
+// client:
+//
+//	prometheus.MustRegister(hybridlogprom.NewCollector(h))
+package hybridlogprom
+
+import (
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects the counters of a logger on each scrape
+type Collector struct {
+	h *hybridlog.HybridLogger
+
+	entries   *prometheus.Desc
+	written   *prometheus.Desc
+	rotations *prometheus.Desc
+	errors    *prometheus.Desc
+	dropped   *prometheus.Desc
+	fileSize  *prometheus.Desc
+}
+
+// NewCollector returns a collector for h. Register one per logger, the
+// metrics of two would clash
+func NewCollector(h *hybridlog.HybridLogger) *Collector {
+	return &Collector{
+		h:         h,
+		entries:   prometheus.NewDesc("hybridlog_entries_total", "Log entries written by level.", []string{"level"}, nil),
+		written:   prometheus.NewDesc("hybridlog_written_bytes_total", "Bytes written to the log files.", nil, nil),
+		rotations: prometheus.NewDesc("hybridlog_rotations_total", "Log file rotations.", nil, nil),
+		errors:    prometheus.NewDesc("hybridlog_errors_total", "Failed writes to the log files and outputs.", nil, nil),
+		dropped:   prometheus.NewDesc("hybridlog_dropped_entries_total", "Entries dropped because the async queue was full.", nil, nil),
+		fileSize:  prometheus.NewDesc("hybridlog_file_size_bytes", "Size of the log files being written.", []string{"file"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.written
+	ch <- c.rotations
+	ch <- c.errors
+	ch <- c.dropped
+	ch <- c.fileSize
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.h.Stats()
+	for level, n := range s.Entries {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.CounterValue, float64(n), level)
+	}
+	ch <- prometheus.MustNewConstMetric(c.written, prometheus.CounterValue, float64(s.Bytes))
+	ch <- prometheus.MustNewConstMetric(c.rotations, prometheus.CounterValue, float64(s.Rotations))
+	ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.Errors))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped))
+	for name, size := range s.Files {
+		ch <- prometheus.MustNewConstMetric(c.fileSize, prometheus.GaugeValue, float64(size), name)
+	}
+}
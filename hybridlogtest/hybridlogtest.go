@@ -0,0 +1,136 @@
+// Package hybridlogtest captures the entries of a HybridLogger in memory so
+// tests can assert on what was logged without touching the filesystem
+package hybridlogtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a captured log entry
+type Entry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  logrus.Fields
+}
+
+// Recorder is a hook keeping every entry it fires for, add it to an existing
+// logger with AddHook or use New
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New returns a logger writing no files, at trace level unless opts set
+// another one, and the Recorder capturing its entries. The logger is closed
+// when the test ends
+func New(t testing.TB, opts ...hybridlog.Option) (*hybridlog.HybridLogger, *Recorder) {
+	t.Helper()
+
+	opts = append([]hybridlog.Option{hybridlog.WithLevel(6)}, opts...)
+	opts = append(opts, hybridlog.WithoutFile())
+	h, err := hybridlog.New(opts...)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+
+	r := &Recorder{}
+	h.AddHook(r)
+	return h, r
+}
+
+func (r *Recorder) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (r *Recorder) Fire(e *logrus.Entry) error {
+	fields := make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		fields[k] = v
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, Entry{Time: e.Time, Level: e.Level, Message: e.Message, Fields: fields})
+	return nil
+}
+
+// Entries returns the captured entries, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Entry(nil), r.entries...)
+}
+
+// LastEntry returns the most recent entry, nil if nothing was logged
+func (r *Recorder) LastEntry() *Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) == 0 {
+		return nil
+	}
+	e := r.entries[len(r.entries)-1]
+	return &e
+}
+
+// Reset discards the captured entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// contains reports whether an entry of level has substr in its message
+func (r *Recorder) contains(level logrus.Level, substr string) bool {
+	for _, e := range r.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertContains fails the test unless an entry of level has substr in its message
+func (r *Recorder) AssertContains(t testing.TB, level logrus.Level, substr string) {
+	t.Helper()
+	if !r.contains(level, substr) {
+		t.Errorf("no %s entry containing %q, logged:\n%s", level, substr, r)
+	}
+}
+
+// AssertNotContains fails the test if an entry of level has substr in its message
+func (r *Recorder) AssertNotContains(t testing.TB, level logrus.Level, substr string) {
+	t.Helper()
+	if r.contains(level, substr) {
+		t.Errorf("unexpected %s entry containing %q, logged:\n%s", level, substr, r)
+	}
+}
+
+// String lists the captured entries, one per line
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		fmt.Fprintf(&b, "%s %s", e.Level, e.Message)
+		keys := make([]string, 0, len(e.Fields))
+		for k := range e.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
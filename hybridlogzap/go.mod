@@ -0,0 +1,20 @@
+module github.com/git4rakesh/hybrid_log/hybridlogzap
+
+go 1.24.4
+
+// The released hybrid_log is required, to build against a checkout use a
+// workspace in the root of the repository, which .gitignore leaves out:
+//
+//	go work init . ./hybridlogzap
+//	go work edit -replace github.com/git4rakesh/hybrid_log@v0.1.0=.
+require (
+	github.com/git4rakesh/hybrid_log v0.1.0
+	github.com/sirupsen/logrus v1.9.3
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
@@ -0,0 +1,66 @@
+// Package hybridlogzap backs a HybridLogger with a zap encoder, it is a
+// module of its own so that the logger doesn't depend on zap:
+//
+//	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+//	h, err := hybridlog.New(hybridlog.WithEngine(hybridlogzap.Engine(enc)))
+package hybridlogzap
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Engine returns an engine encoding entries with enc, whose EncoderConfig
+// decides the keys and the time and level encoding
+func Engine(enc zapcore.Encoder) hybridlog.Engine {
+	return &engine{enc: enc}
+}
+
+type engine struct {
+	enc zapcore.Encoder
+}
+
+// Encode implements hybridlog.Engine
+func (z *engine) Encode(w io.Writer, e *logrus.Entry) error {
+	ent := zapcore.Entry{Level: zapLevel(e.Level), Time: e.Time, Message: e.Message}
+	if e.HasCaller() {
+		ent.Caller = zapcore.EntryCaller{Defined: true, PC: e.Caller.PC, File: e.Caller.File, Line: e.Caller.Line, Function: e.Caller.Function}
+	}
+	fields := make([]zapcore.Field, 0, len(e.Data))
+	for k, v := range e.Data {
+		fields = append(fields, zap.Any(k, v))
+	}
+	slices.SortFunc(fields, func(a, b zapcore.Field) int { return strings.Compare(a.Key, b.Key) })
+
+	// EncodeEntry works on a clone, so the encoder is shared by all entries
+	buf, err := z.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// zapLevel maps a logrus level to zap's, which has no trace level
+func zapLevel(l logrus.Level) zapcore.Level {
+	switch l {
+	case logrus.PanicLevel:
+		return zapcore.PanicLevel
+	case logrus.FatalLevel:
+		return zapcore.FatalLevel
+	case logrus.ErrorLevel:
+		return zapcore.ErrorLevel
+	case logrus.WarnLevel:
+		return zapcore.WarnLevel
+	case logrus.InfoLevel:
+		return zapcore.InfoLevel
+	}
+	return zapcore.DebugLevel
+}
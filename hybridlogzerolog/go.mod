@@ -0,0 +1,21 @@
+module github.com/git4rakesh/hybrid_log/hybridlogzerolog
+
+go 1.24.4
+
+// The released hybrid_log is required, to build against a checkout use a
+// workspace in the root of the repository, which .gitignore leaves out:
+//
+//	go work init . ./hybridlogzerolog
+//	go work edit -replace github.com/git4rakesh/hybrid_log@v0.1.0=.
+require (
+	github.com/git4rakesh/hybrid_log v0.1.0
+	github.com/rs/zerolog v1.33.0
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
@@ -0,0 +1,58 @@
+// Package hybridlogzerolog backs a HybridLogger with zerolog's encoding, it
+// is a module of its own so that the logger doesn't depend on zerolog:
+//
+//	h, err := hybridlog.New(hybridlog.WithEngine(hybridlogzerolog.Engine()))
+package hybridlogzerolog
+
+import (
+	"io"
+
+	hybridlog "github.com/git4rakesh/hybrid_log"
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+)
+
+// Engine returns an engine writing entries as zerolog does, with its field
+// names and time format such as zerolog.TimestampFieldName and
+// zerolog.TimeFieldFormat
+func Engine() hybridlog.Engine {
+	return engine{}
+}
+
+type engine struct{}
+
+// Encode implements hybridlog.Engine. The logger's level applies, but
+// entries below zerolog's global level, see zerolog.SetGlobalLevel, are
+// left out
+func (engine) Encode(w io.Writer, e *logrus.Entry) error {
+	l := zerolog.New(w)
+	ev := l.WithLevel(zerologLevel(e.Level)) // WithLevel neither exits nor panics
+	if ev == nil {
+		return nil
+	}
+	ev = ev.Time(zerolog.TimestampFieldName, e.Time)
+	if e.HasCaller() {
+		ev = ev.Str(zerolog.CallerFieldName, zerolog.CallerMarshalFunc(e.Caller.PC, e.Caller.File, e.Caller.Line))
+	}
+	ev.Fields(map[string]interface{}(e.Data)).Msg(e.Message) // fields sorted by key
+	return nil
+}
+
+// zerologLevel maps a logrus level to zerolog's
+func zerologLevel(l logrus.Level) zerolog.Level {
+	switch l {
+	case logrus.PanicLevel:
+		return zerolog.PanicLevel
+	case logrus.FatalLevel:
+		return zerolog.FatalLevel
+	case logrus.ErrorLevel:
+		return zerolog.ErrorLevel
+	case logrus.WarnLevel:
+		return zerolog.WarnLevel
+	case logrus.InfoLevel:
+		return zerolog.InfoLevel
+	case logrus.DebugLevel:
+		return zerolog.DebugLevel
+	}
+	return zerolog.TraceLevel
+}
@@ -0,0 +1,156 @@
+//go:build linux
+
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldWriter sends entries to systemd-journald over its native protocol,
+// with the entry fields as journal fields
+type journaldWriter struct {
+	mu         sync.Mutex
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+func newJournaldWriter(identifier string) (*journaldWriter, error) {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	if _, err := os.Stat(journaldSocket); err != nil {
+		return nil, fmt.Errorf("journald is not available: %v", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journald socket: %v", err)
+	}
+	return &journaldWriter{
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: journaldSocket, Net: "unixgram"},
+		identifier: identifier,
+	}, nil
+}
+
+// Write sends preformatted bytes as the message of an info entry
+func (j *journaldWriter) Write(p []byte) (int, error) {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", string(bytes.TrimRight(p, "\n")))
+	writeJournalField(&b, "PRIORITY", "6")
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", j.identifier)
+	return len(p), j.send(b.Bytes())
+}
+
+// WriteEntry sends the entry with its fields as journal fields
+func (j *journaldWriter) WriteEntry(e *logrus.Entry) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.Message)
+	writeJournalField(&b, "PRIORITY", fmt.Sprint(syslogSeverity[e.Level]))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", j.identifier)
+	if e.HasCaller() {
+		writeJournalField(&b, "CODE_FILE", e.Caller.File)
+		writeJournalField(&b, "CODE_LINE", fmt.Sprint(e.Caller.Line))
+		writeJournalField(&b, "CODE_FUNC", e.Caller.Function)
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeJournalField(&b, journalFieldName(k), logfmtValue(e.Data[k]))
+	}
+	return j.send(b.Bytes())
+}
+
+func (j *journaldWriter) send(msg []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err := j.conn.WriteToUnix(msg, j.addr)
+	if err == nil || !isMsgSize(err) {
+		return err
+	}
+	// Too large for a datagram: pass it in an unlinked file descriptor
+	f, err := os.CreateTemp("/dev/shm", "journal.*")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.addr)
+	return err
+}
+
+// Close closes the journald socket
+func (j *journaldWriter) Close() error {
+	return j.conn.Close()
+}
+
+func isMsgSize(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
+			return sysErr.Err == syscall.EMSGSIZE || sysErr.Err == syscall.ENOBUFS
+		}
+	}
+	return false
+}
+
+// writeJournalField writes KEY=value, or the binary form for values with newlines
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	b.WriteString(key)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalReserved are the fields the writer sets itself, user fields by
+// these names would add a second value to them
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// journalFieldName converts a field key to a valid journal field name:
+// upper case letters, digits and underscores, not starting with an underscore
+// and not one of the reserved fields
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] == '_' || name[0] >= '0' && name[0] <= '9' || journalReserved[string(name)] {
+		return "F_" + string(name)
+	}
+	return string(name)
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package hybridlog
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+type journaldWriter struct{}
+
+func newJournaldWriter(identifier string) (*journaldWriter, error) {
+	return nil, errors.New("journald is only supported on linux")
+}
+
+func (j *journaldWriter) Write(p []byte) (int, error)      { return len(p), nil }
+func (j *journaldWriter) WriteEntry(e *logrus.Entry) error { return nil }
+func (j *journaldWriter) Close() error                     { return nil }
@@ -0,0 +1,63 @@
+package hybridlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// updateLink points the latest link of f at the file being written: a
+// relative symlink, or on Windows, where symlinks need privileges, a hard
+// link or failing that a copy refreshed at each rotation
+func (f *rotatingFile) updateLink() {
+	if f.link == "" {
+		return
+	}
+	target := f.lumber.Filename
+	if err := replaceLink(target, f.link); err != nil && f.report != nil {
+		f.report(err)
+	}
+}
+
+func replaceLink(target, link string) error {
+	if runtime.GOOS == "windows" {
+		os.Remove(link)
+		if err := os.Link(target, link); err == nil {
+			return nil
+		}
+		return copyFile(target, link)
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(link), target)
+	if err != nil {
+		rel = target
+	}
+	if cur, err := os.Readlink(link); err == nil && cur == rel {
+		return nil
+	}
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(rel, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link) // replaces the old link atomically
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,73 @@
+package hybridlog
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LazyValue is a field value computed only for entries that are written,
+// those below the level or left out by sampling, rate limits and filters
+// never call it
+type LazyValue struct {
+	fn func() interface{}
+}
+
+// Lazy returns a field value computed by fn once the entry is known to be
+// written, e.g. WithField("dump", Lazy(func() interface{} { return expensive() })).
+// A func() interface{} passed to WithField or WithFields is made lazy the
+// same way, logrus would drop it otherwise
+func Lazy(fn func() interface{}) LazyValue {
+	return LazyValue{fn: fn}
+}
+
+// Value calls the function of the value
+func (l LazyValue) Value() interface{} { return l.fn() }
+
+// String implements fmt.Stringer, for formatters that see the value unresolved
+func (l LazyValue) String() string { return fmt.Sprint(l.fn()) }
+
+// MarshalJSON implements json.Marshaler, for formatters that see the value unresolved
+func (l LazyValue) MarshalJSON() ([]byte, error) { return json.Marshal(l.fn()) }
+
+// lazyField makes a func() interface{} field value lazy
+func lazyField(v interface{}) interface{} {
+	if fn, ok := v.(func() interface{}); ok {
+		return Lazy(fn)
+	}
+	return v
+}
+
+// lazyFields makes the func() interface{} values of fields lazy, copying
+// fields rather than changing the caller's map
+func lazyFields(fields logrus.Fields) logrus.Fields {
+	for _, v := range fields {
+		if _, ok := v.(func() interface{}); ok {
+			copied := make(logrus.Fields, len(fields))
+			for k, v := range fields {
+				copied[k] = lazyField(v)
+			}
+			return copied
+		}
+	}
+	return fields
+}
+
+// lazyHook computes the lazy values of entries, once the level, sampling,
+// rate limits and filters had a chance to drop them. Filters see LazyValue
+type lazyHook struct{}
+
+func (l *lazyHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (l *lazyHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	for k, v := range e.Data {
+		if lazy, ok := v.(LazyValue); ok {
+			e.Data[k] = lazy.fn()
+		}
+	}
+	return nil
+}
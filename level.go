@@ -0,0 +1,112 @@
+package hybridlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ParseLevel converts a level name ("trace", "debug", "info", "warn",
+// "error", "fatal", "panic") to its int value used by SetLogLevel
+func ParseLevel(name string) (int, error) {
+	lvl, err := logrus.ParseLevel(strings.TrimSpace(name))
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidLevel, name)
+	}
+	return int(lvl), nil
+}
+
+// SetLogLevelString changes log level at runtime by name, unknown names are
+// rejected and leave the level unchanged
+func (h *HybridLogger) SetLogLevelString(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	h.SetLogLevel(level)
+	return nil
+}
+
+// LogLevel returns the current log level as used by SetLogLevel
+func (h *HybridLogger) LogLevel() int {
+	return int(h.level())
+}
+
+// GetLevel returns the active level, which with a ring buffer differs from
+// that of the embedded logrus logger
+func (h *HybridLogger) GetLevel() logrus.Level {
+	return h.level()
+}
+
+// SetLevel sets the level of this logger only, unlike SetLogLevel on the
+// root logger
+func (h *HybridLogger) SetLevel(lvl logrus.Level) {
+	h.setLevel(lvl)
+}
+
+// IsLevelEnabled reports whether entries of level are written, a single
+// atomic read to guard building expensive arguments. Levels are numbered as
+// for SetLogLevel, e.g. IsLevelEnabled(5) for debug, and invalid levels are
+// taken as info like there
+func (h *HybridLogger) IsLevelEnabled(level int) bool {
+	lvl, ok := levelMap[level]
+	if !ok {
+		lvl = logrus.InfoLevel
+	}
+	return h.level() >= lvl
+}
+
+// IsDebugEnabled reports whether Debug entries are written
+func (h *HybridLogger) IsDebugEnabled() bool {
+	return h.level() >= logrus.DebugLevel
+}
+
+// IsTraceEnabled reports whether Trace entries are written
+func (h *HybridLogger) IsTraceEnabled() bool {
+	return h.level() >= logrus.TraceLevel
+}
+
+// LogLevelString returns the name of the current log level
+func (h *HybridLogger) LogLevelString() string {
+	return h.level().String()
+}
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an admin handler for the log level. GET returns
+// {"level":"info"}, PUT takes the same JSON body (or a plain level name) and
+// changes the level at runtime
+func (h *HybridLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var req levelPayload
+			if err := json.Unmarshal(body, &req); err != nil {
+				req.Level = string(body)
+			}
+			if err := h.SetLogLevelString(req.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			h.Logger.Infof("log level changed to %s", h.LogLevelString())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelPayload{Level: h.LogLevelString()})
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !netbsd && !dragonfly && !windows
+
+package hybridlog
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoLocking = errors.New("file locking is not available on this platform")
+
+func lockFile(f *os.File) error {
+	return errNoLocking
+}
+
+func unlockFile(f *os.File) error {
+	return errNoLocking
+}
@@ -0,0 +1,99 @@
+package hybridlog
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is the logging API of HybridLogger, for libraries that accept a
+// logger without depending on how it's set up. See Nop for tests and benchmarks
+type Logger interface {
+	Trace(args ...interface{})
+	Tracef(format string, args ...interface{})
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Panic(args ...interface{})
+	Panicf(format string, args ...interface{})
+
+	WithField(key string, value interface{}) *Entry
+	WithFields(fields logrus.Fields) *Entry
+	WithError(err error) *Entry
+
+	SetLogLevel(level int)
+	LogLevel() int
+}
+
+var (
+	_ Logger = (*HybridLogger)(nil)
+	_ Logger = (*nopLogger)(nil)
+)
+
+// nopBase backs the entries of Nop, only Panic entries pass its level and
+// they are formatted into io.Discard
+var nopBase = &logrus.Logger{
+	Out:       io.Discard,
+	Formatter: &logrus.TextFormatter{},
+	Hooks:     logrus.LevelHooks{},
+	Level:     logrus.PanicLevel,
+	ExitFunc:  func(code int) {},
+}
+
+// nopLogger discards everything, it only remembers its level
+type nopLogger struct {
+	level atomic.Int32
+}
+
+// Nop returns a Logger that discards every entry. Panic still panics so
+// code relying on it not returning behaves the same, Fatal returns
+func Nop() Logger {
+	n := &nopLogger{}
+	n.level.Store(int32(logrus.InfoLevel))
+	return n
+}
+
+func (n *nopLogger) Trace(args ...interface{})                 {}
+func (n *nopLogger) Tracef(format string, args ...interface{}) {}
+func (n *nopLogger) Debug(args ...interface{})                 {}
+func (n *nopLogger) Debugf(format string, args ...interface{}) {}
+func (n *nopLogger) Info(args ...interface{})                  {}
+func (n *nopLogger) Infof(format string, args ...interface{})  {}
+func (n *nopLogger) Warn(args ...interface{})                  {}
+func (n *nopLogger) Warnf(format string, args ...interface{})  {}
+func (n *nopLogger) Error(args ...interface{})                 {}
+func (n *nopLogger) Errorf(format string, args ...interface{}) {}
+func (n *nopLogger) Fatal(args ...interface{})                 {}
+func (n *nopLogger) Fatalf(format string, args ...interface{}) {}
+func (n *nopLogger) Panic(args ...interface{})                 { nopBase.Panic(args...) }
+func (n *nopLogger) Panicf(format string, args ...interface{}) { nopBase.Panicf(format, args...) }
+
+func (n *nopLogger) WithField(key string, value interface{}) *Entry {
+	return &Entry{Entry: nopBase.WithField(key, value)}
+}
+
+func (n *nopLogger) WithFields(fields logrus.Fields) *Entry {
+	return &Entry{Entry: nopBase.WithFields(fields)}
+}
+
+func (n *nopLogger) WithError(err error) *Entry {
+	return &Entry{Entry: nopBase.WithError(err)}
+}
+
+func (n *nopLogger) SetLogLevel(level int) {
+	lvl, ok := levelMap[level]
+	if !ok {
+		lvl = logrus.InfoLevel
+	}
+	n.level.Store(int32(lvl))
+}
+
+func (n *nopLogger) LogLevel() int { return int(n.level.Load()) }
@@ -0,0 +1,79 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LokiOptions configures the Grafana Loki push output
+type LokiOptions struct {
+	URL       string            // Loki base URL, e.g. http://loki:3100
+	Labels    map[string]string // static stream labels such as app and host, level is always added
+	TenantID  string            // X-Scope-OrgID for multi-tenant Loki
+	Username  string            // basic auth user
+	Password  string            // basic auth password
+	Formatter logrus.Formatter  // line formatter, defaults to the file formatter
+	Batch     BatchOptions
+	Client    *http.Client // defaults to a client with a 10s timeout
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// newLokiWriter returns a batching writer pushing to Loki's /loki/api/v1/push,
+// one stream per level
+func newLokiWriter(opts LokiOptions) *batcher {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	url := strings.TrimRight(opts.URL, "/") + "/loki/api/v1/push"
+
+	return newBatcher(opts.Batch, func(batch []batchRecord) error {
+		streams := map[logrus.Level]*lokiStream{}
+		var order []logrus.Level
+		for _, rec := range batch {
+			s, ok := streams[rec.level]
+			if !ok {
+				labels := map[string]string{"level": rec.level.String()}
+				for k, v := range opts.Labels {
+					labels[k] = v
+				}
+				s = &lokiStream{Stream: labels}
+				streams[rec.level] = s
+				order = append(order, rec.level)
+			}
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(rec.time.UnixNano(), 10), string(rec.line)})
+		}
+		payload := struct {
+			Streams []*lokiStream `json:"streams"`
+		}{}
+		for _, lvl := range order {
+			payload.Streams = append(payload.Streams, streams[lvl])
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return permanentError{err}
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if opts.TenantID != "" {
+			req.Header.Set("X-Scope-OrgID", opts.TenantID)
+		}
+		if opts.Username != "" {
+			req.SetBasicAuth(opts.Username, opts.Password)
+		}
+		return doHTTP(opts.Client, req)
+	})
+}
@@ -0,0 +1,139 @@
+package hybridlog
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// metrics counts the logger's activity, see MetricsHandler
+type metrics struct {
+	entries   [logrus.TraceLevel + 1]atomic.Uint64 // written entries by level
+	rotations atomic.Uint64
+}
+
+// Stats is a snapshot of the logger's counters, see Stats
+type Stats struct {
+	Entries       map[string]uint64 // entries written by level name
+	Writes        uint64            // successful writes to the log files
+	Bytes         uint64            // bytes written to the log files
+	Rotations     uint64
+	Errors        uint64 // see ErrorCount
+	LastError     string // empty if no error occurred
+	LastErrorTime time.Time
+	Dropped       uint64 // see Dropped
+	QueueDepth    int    // entries waiting in the async queue
+	QueueCapacity int    // 0 unless async
+
+	Files map[string]int64 // size of the files being written, by name
+}
+
+// Stats returns the current counters of the logger
+func (h *HybridLogger) Stats() Stats {
+	s := Stats{
+		Entries:   make(map[string]uint64, len(logrus.AllLevels)),
+		Rotations: h.metrics.rotations.Load(),
+		Errors:    h.ErrorCount(),
+		Dropped:   h.Dropped(),
+	}
+	for _, lvl := range logrus.AllLevels {
+		s.Entries[lvl.String()] = h.metrics.entries[lvl].Load()
+	}
+	for _, f := range h.files() {
+		s.Writes += f.writes.Load()
+		s.Bytes += f.written.Load()
+	}
+
+	h.errMu.RLock()
+	if h.lastErr != nil {
+		s.LastError, s.LastErrorTime = h.lastErr.Error(), h.lastErrAt
+	}
+	h.errMu.RUnlock()
+
+	if h.async != nil {
+		s.QueueDepth, s.QueueCapacity = len(h.async.ch), cap(h.async.ch)
+	}
+	s.Files = h.fileSizes()
+	return s
+}
+
+// fileSizes returns the size of the files being written, by their name
+// without the date
+func (h *HybridLogger) fileSizes() map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, f := range h.files() {
+		f.mu.Lock()
+		name := f.lumber.Filename
+		f.mu.Unlock()
+		var size int64
+		if info, err := os.Stat(name); err == nil {
+			size = info.Size()
+		}
+		sizes[filepath.Base(undatedName(f.fileName))] = size
+	}
+	return sizes
+}
+
+// PublishExpvar publishes Stats under name in expvar, served as JSON on
+// /debug/vars. Like expvar.Publish it panics if name is already in use
+func (h *HybridLogger) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return h.Stats() }))
+}
+
+// MetricsHandler serves the logger's counters in the Prometheus text
+// format, for a scrape target such as /metrics. The hybridlogprom package
+// has them as a prometheus.Collector instead:
+//
+//	hybridlog_entries_total{level="error"}  entries written by level
+//	hybridlog_written_bytes_total           bytes written to the log files
+//	hybridlog_rotations_total               file rotations
+//	hybridlog_errors_total                  failed writes and outputs, see OnError
+//	hybridlog_dropped_entries_total         entries dropped by a full async queue
+//	hybridlog_file_size_bytes{file="..."}   size of the files being written
+func (h *HybridLogger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		h.writeMetrics(w)
+	})
+}
+
+func (h *HybridLogger) writeMetrics(w io.Writer) {
+	fmt.Fprintln(w, "# HELP hybridlog_entries_total Log entries written by level.")
+	fmt.Fprintln(w, "# TYPE hybridlog_entries_total counter")
+	for _, lvl := range logrus.AllLevels {
+		fmt.Fprintf(w, "hybridlog_entries_total{level=%q} %d\n", lvl.String(), h.metrics.entries[lvl].Load())
+	}
+
+	var written uint64
+	for _, f := range h.files() {
+		written += f.written.Load()
+	}
+	writeCounter(w, "hybridlog_written_bytes_total", "Bytes written to the log files.", written)
+	writeCounter(w, "hybridlog_rotations_total", "Log file rotations.", h.metrics.rotations.Load())
+	writeCounter(w, "hybridlog_errors_total", "Failed writes to the log files and outputs.", h.ErrorCount())
+	writeCounter(w, "hybridlog_dropped_entries_total", "Entries dropped because the async queue was full.", h.Dropped())
+
+	fmt.Fprintln(w, "# HELP hybridlog_file_size_bytes Size of the log files being written.")
+	fmt.Fprintln(w, "# TYPE hybridlog_file_size_bytes gauge")
+	sizes := h.fileSizes()
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "hybridlog_file_size_bytes{file=%q} %d\n", name, sizes[name])
+	}
+}
+
+func writeCounter(w io.Writer, name, help string, v uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+}
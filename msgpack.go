@@ -0,0 +1,233 @@
+package hybridlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Minimal msgpack encoding for the Fluentd forward protocol. Values other
+// than the basic types are encoded as their string form
+
+func msgpackAppend(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return msgpackAppendInt(b, int64(v))
+	case int8:
+		return msgpackAppendInt(b, int64(v))
+	case int16:
+		return msgpackAppendInt(b, int64(v))
+	case int32:
+		return msgpackAppendInt(b, int64(v))
+	case int64:
+		return msgpackAppendInt(b, v)
+	case uint:
+		return msgpackAppendUint(b, uint64(v))
+	case uint8:
+		return msgpackAppendUint(b, uint64(v))
+	case uint16:
+		return msgpackAppendUint(b, uint64(v))
+	case uint32:
+		return msgpackAppendUint(b, uint64(v))
+	case uint64:
+		return msgpackAppendUint(b, v)
+	case float32:
+		return msgpackAppendFloat(b, float64(v))
+	case float64:
+		return msgpackAppendFloat(b, v)
+	case string:
+		return msgpackAppendString(b, v)
+	case []byte:
+		return msgpackAppendBin(b, v)
+	case time.Time:
+		return msgpackAppendEventTime(b, v)
+	case error:
+		return msgpackAppendString(b, v.Error())
+	case []interface{}:
+		b = msgpackAppendArrayHeader(b, len(v))
+		for _, e := range v {
+			b = msgpackAppend(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = msgpackAppendMapHeader(b, len(v))
+		for _, k := range keys {
+			b = msgpackAppendString(b, k)
+			b = msgpackAppend(b, v[k])
+		}
+		return b
+	case fmt.Stringer:
+		return msgpackAppendString(b, v.String())
+	}
+	return msgpackAppendString(b, fmt.Sprint(v))
+}
+
+func msgpackAppendInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return msgpackAppendUint(b, uint64(v))
+	}
+	if v >= -32 {
+		return append(b, byte(v))
+	}
+	b = append(b, 0xd3)
+	return binary.BigEndian.AppendUint64(b, uint64(v))
+}
+
+func msgpackAppendUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 128:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+}
+
+func msgpackAppendFloat(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+}
+
+func msgpackAppendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func msgpackAppendBin(b []byte, v []byte) []byte {
+	switch n := len(v); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, v...)
+}
+
+func msgpackAppendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+}
+
+func msgpackAppendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+// msgpackAppendEventTime encodes t as a Fluentd EventTime (ext type 0)
+func msgpackAppendEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// msgpackReadStringMap decodes a map of string keys and values, the shape of
+// a Fluentd ack response
+func msgpackReadStringMap(r io.Reader) (map[string]string, error) {
+	n, err := msgpackReadHeader(r, 0x80, 0x8f, 0xde, 0xdf)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k, err := msgpackReadString(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := msgpackReadString(r)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func msgpackReadString(r io.Reader) (string, error) {
+	var head [1]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return "", err
+	}
+	var n int
+	switch c := head[0]; {
+	case c >= 0xa0 && c <= 0xbf:
+		n = int(c & 0x1f)
+	case c == 0xd9:
+		var l [1]byte
+		if _, err := io.ReadFull(r, l[:]); err != nil {
+			return "", err
+		}
+		n = int(l[0])
+	case c == 0xda:
+		var l [2]byte
+		if _, err := io.ReadFull(r, l[:]); err != nil {
+			return "", err
+		}
+		n = int(binary.BigEndian.Uint16(l[:]))
+	default:
+		return "", fmt.Errorf("msgpack: expected string, got 0x%x", c)
+	}
+	s := make([]byte, n)
+	_, err := io.ReadFull(r, s)
+	return string(s), err
+}
+
+// msgpackReadHeader reads a map or array header given its fix range and 16/32 bit codes
+func msgpackReadHeader(r io.Reader, fixMin, fixMax, code16, code32 byte) (int, error) {
+	var head [1]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, err
+	}
+	switch c := head[0]; {
+	case c >= fixMin && c <= fixMax:
+		return int(c - fixMin), nil
+	case c == code16:
+		var l [2]byte
+		_, err := io.ReadFull(r, l[:])
+		return int(binary.BigEndian.Uint16(l[:])), err
+	case c == code32:
+		var l [4]byte
+		_, err := io.ReadFull(r, l[:])
+		return int(binary.BigEndian.Uint32(l[:])), err
+	}
+	return 0, errors.New("msgpack: unexpected type")
+}
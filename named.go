@@ -0,0 +1,182 @@
+package hybridlog
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sharedHooks fires the hooks kept in core, so that hooks registered on the
+// root logger also run for the loggers derived from it
+type sharedHooks struct {
+	c *core
+}
+
+func (s *sharedHooks) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (s *sharedHooks) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil // below the level, see levelGate
+	}
+	s.c.hookMu.RLock()
+	hooks := s.c.hooks[e.Level]
+	s.c.hookMu.RUnlock()
+
+	var firstErr error
+	for _, hook := range hooks {
+		if err := hook.Fire(e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// fixedFieldsHook stamps the fields of a derived logger on its entries,
+// explicit fields take precedence
+type fixedFieldsHook struct {
+	fields logrus.Fields
+}
+
+func (f *fixedFieldsHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (f *fixedFieldsHook) Fire(e *logrus.Entry) error {
+	for k, v := range f.fields {
+		if _, ok := e.Data[k]; !ok {
+			e.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// derive returns a logger writing through the same core as h, with its own
+// level and the given fields stamped on every entry
+func (h *HybridLogger) derive(fields logrus.Fields) *HybridLogger {
+	l := logrus.New()
+	l.SetOutput(h.Logger.Out)
+	l.SetFormatter(h.Logger.Formatter)
+	l.SetReportCaller(h.Logger.ReportCaller)
+	l.ExitFunc = h.Logger.ExitFunc
+
+	stamped := logrus.Fields{}
+	for k, v := range h.fields {
+		stamped[k] = v
+	}
+	for k, v := range fields {
+		stamped[k] = lazyField(v)
+	}
+	l.AddHook(&fixedFieldsHook{fields: stamped})
+	var gate *levelGate
+	if h.ring != nil {
+		gate = &levelGate{c: h.core}
+		l.AddHook(gate)
+	}
+	l.AddHook(&sharedHooks{c: h.core})
+
+	d := &HybridLogger{Logger: l, core: h.core, fields: stamped, gate: gate}
+	d.setLevel(h.level())
+	return d
+}
+
+// Child returns a logger sharing the output and rotation of h with fields
+// stamped on every entry, on top of any fields h already carries. The child
+// starts at the level of h and can be changed independently
+func (h *HybridLogger) Child(fields logrus.Fields) *HybridLogger {
+	return h.derive(fields)
+}
+
+// namedLogger is a registry entry, override is set once the name has a level
+// of its own instead of following the root logger
+type namedLogger struct {
+	h        *HybridLogger
+	override bool
+}
+
+// Named returns the logger registered under name, creating it on first use.
+// Named loggers share the file of h, carry a "component" field and have
+// their own level, which follows the root logger until SetNamedLevel is used
+func (h *HybridLogger) Named(name string) *HybridLogger {
+	h.namedMu.Lock()
+	defer h.namedMu.Unlock()
+
+	if n, ok := h.named[name]; ok {
+		return n.h
+	}
+	n := &namedLogger{h: h.root.derive(logrus.Fields{"component": name})}
+	h.named[name] = n
+	return n.h
+}
+
+// SetNamedLevel sets the level of the named logger at runtime, creating it if needed
+func (h *HybridLogger) SetNamedLevel(name string, level int) {
+	named := h.Named(name)
+
+	h.namedMu.Lock()
+	defer h.namedMu.Unlock()
+
+	h.named[name].override = true
+	named.SetLogLevel(level)
+}
+
+// ResetNamedLevel makes the named logger follow the root logger level again
+func (h *HybridLogger) ResetNamedLevel(name string) {
+	h.namedMu.Lock()
+	defer h.namedMu.Unlock()
+
+	if n, ok := h.named[name]; ok {
+		n.override = false
+		n.h.setLevel(h.root.level())
+	}
+}
+
+// NamedLevels returns the level of every registered named logger
+func (h *HybridLogger) NamedLevels() map[string]int {
+	h.namedMu.Lock()
+	defer h.namedMu.Unlock()
+
+	levels := make(map[string]int, len(h.named))
+	for name, n := range h.named {
+		levels[name] = n.h.LogLevel()
+	}
+	return levels
+}
+
+// syncNamedLevels applies a root level change to named loggers that follow it
+func (h *HybridLogger) syncNamedLevels(lvl logrus.Level) {
+	h.namedMu.Lock()
+	defer h.namedMu.Unlock()
+
+	for _, n := range h.named {
+		if !n.override {
+			n.h.setLevel(lvl)
+		}
+	}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *HybridLogger
+)
+
+// SetDefault sets the logger used by the package-level functions such as
+// Info, WithField and Get
+func SetDefault(h *HybridLogger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultLogger = h
+}
+
+// Default returns the logger set by SetDefault
+func Default() *HybridLogger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultLogger
+}
+
+// Get returns the named logger from the default logger, see Named. Until
+// SetDefault is called it comes from the stderr logger of the package-level
+// functions
+func Get(name string) *HybridLogger {
+	return std().Named(name)
+}
@@ -0,0 +1,31 @@
+package hybridlog
+
+import "testing"
+
+func TestGetWithoutDefault(t *testing.T) {
+	defaultMu.Lock()
+	prev := defaultLogger
+	defaultLogger = nil
+	defaultMu.Unlock()
+	defer func() {
+		defaultMu.Lock()
+		defaultLogger = prev
+		defaultMu.Unlock()
+	}()
+
+	db := Get("db")
+	if db == nil || Get("db") != db {
+		t.Fatal("Get should return the same logger for a name")
+	}
+	db.Debug("not written")
+
+	h, err := New(WithLogDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	SetDefault(h)
+	if Get("db") != h.Named("db") {
+		t.Fatal("Get should use the logger set by SetDefault")
+	}
+}
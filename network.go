@@ -0,0 +1,126 @@
+package hybridlog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NetworkOptions configures the raw TCP/UDP output
+type NetworkOptions struct {
+	Network    string           // "tcp" or "udp"
+	Address    string           // collector host:port
+	Formatter  logrus.Formatter // line formatter, defaults to the file formatter
+	MaxBackoff time.Duration    // max wait between reconnect attempts, defaults to 30s
+}
+
+const (
+	minNetworkBackoff   = 100 * time.Millisecond
+	maxNetworkBackoff   = 30 * time.Second
+	networkWriteTimeout = 5 * time.Second
+)
+
+// redialer paces the reconnects of a network output. After a failure no
+// dial is tried until an exponentially growing backoff has passed, entries
+// are dropped meanwhile so that logging never blocks on an unreachable server
+type redialer struct {
+	max      time.Duration // defaults to 30s
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+// ready returns an error while the backoff after a failure lasts
+func (r *redialer) ready(network, address string) error {
+	if time.Now().Before(r.nextDial) {
+		return fmt.Errorf("%s %s unreachable, retrying in %v", network, address, time.Until(r.nextDial).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// fail schedules the next dial with exponential backoff
+func (r *redialer) fail() {
+	max := r.max
+	if max <= 0 {
+		max = maxNetworkBackoff
+	}
+	if r.backoff == 0 {
+		r.backoff = minNetworkBackoff
+	} else if r.backoff *= 2; r.backoff > max {
+		r.backoff = max
+	}
+	r.nextDial = time.Now().Add(r.backoff)
+}
+
+// succeeded resets the backoff
+func (r *redialer) succeeded() {
+	r.backoff = 0
+}
+
+// isTimeout reports whether err is a deadline being exceeded, after which a
+// retry would block as long again
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// networkWriter writes each formatted line to a TCP or UDP endpoint. After a
+// failure it reconnects with exponential backoff, dropping lines meanwhile so
+// that logging never blocks on an unreachable collector
+type networkWriter struct {
+	mu     sync.Mutex
+	opts   NetworkOptions
+	conn   net.Conn
+	redial redialer
+}
+
+func newNetworkWriter(opts NetworkOptions) *networkWriter {
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = maxNetworkBackoff
+	}
+	return &networkWriter{opts: opts, redial: redialer{max: opts.MaxBackoff}}
+}
+
+// Write sends one formatted line
+func (n *networkWriter) Write(p []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.redial.ready(n.opts.Network, n.opts.Address); err != nil {
+			return 0, err
+		}
+		conn, err := net.DialTimeout(n.opts.Network, n.opts.Address, networkWriteTimeout)
+		if err != nil {
+			n.redial.fail()
+			return 0, err
+		}
+		n.conn = conn
+	}
+
+	n.conn.SetWriteDeadline(time.Now().Add(networkWriteTimeout))
+	if _, err := n.conn.Write(p); err != nil {
+		n.conn.Close()
+		n.conn = nil
+		n.redial.fail()
+		return 0, err
+	}
+	n.redial.succeeded()
+	return len(p), nil
+}
+
+// Close closes the connection
+func (n *networkWriter) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
@@ -0,0 +1,124 @@
+package hybridlog
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectStore uploads archived log files, see WithArchive
+type ObjectStore interface {
+	// Put uploads size bytes from body under key
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+}
+
+// S3Store uploads to an AWS S3 (or S3 compatible) bucket
+type S3Store struct {
+	Bucket      string                 // bucket name
+	Region      string                 // AWS region, defaults to AWS_REGION or AWS_DEFAULT_REGION
+	Credentials AWSCredentialsProvider // defaults to DefaultAWSCredentials
+	Endpoint    string                 // S3 compatible endpoint such as MinIO, addressed path-style
+	Client      *http.Client           // defaults to http.DefaultClient
+
+	once sync.Once
+}
+
+func (s *S3Store) init() {
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_REGION")
+	}
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.Credentials == nil {
+		s.Credentials = DefaultAWSCredentials()
+	}
+	if s.Client == nil {
+		s.Client = http.DefaultClient
+	}
+}
+
+// Put uploads body with a single PutObject request
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	s.once.Do(s.init)
+
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	path := strings.Join(segments, "/")
+	var rawURL string
+	if s.Endpoint != "" {
+		rawURL = strings.TrimRight(s.Endpoint, "/") + "/" + awsEscape(s.Bucket) + "/" + path
+	} else {
+		rawURL = "https://" + s.Bucket + ".s3." + s.Region + ".amazonaws.com/" + path
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	creds, err := s.Credentials()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	signAWSv4(req, "UNSIGNED-PAYLOAD", creds, s.Region, "s3", time.Now())
+	return doHTTP(s.Client, req)
+}
+
+// GCSStore uploads to a Google Cloud Storage bucket
+type GCSStore struct {
+	Bucket          string         // bucket name
+	CredentialsFile string         // service account key, defaults to GOOGLE_APPLICATION_CREDENTIALS then the metadata server
+	TokenSource     GCPTokenSource // custom access tokens, overrides CredentialsFile
+	Endpoint        string         // defaults to https://storage.googleapis.com
+	Client          *http.Client   // defaults to http.DefaultClient
+
+	once    sync.Once
+	initErr error
+}
+
+func (g *GCSStore) init() {
+	if g.Client == nil {
+		g.Client = http.DefaultClient
+	}
+	if g.Endpoint == "" {
+		g.Endpoint = "https://storage.googleapis.com"
+	}
+	if g.TokenSource == nil {
+		g.TokenSource, _, g.initErr = gcpCredentials(g.CredentialsFile, "https://www.googleapis.com/auth/devstorage.read_write", g.Client)
+	}
+}
+
+// Put uploads body with a single media upload request
+func (g *GCSStore) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	g.once.Do(g.init)
+	if g.initErr != nil {
+		return g.initErr
+	}
+
+	token, err := g.TokenSource()
+	if err != nil {
+		return err
+	}
+	u := strings.TrimRight(g.Endpoint, "/") + "/upload/storage/v1/b/" + url.PathEscape(g.Bucket) +
+		"/o?uploadType=media&name=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doHTTP(g.Client, req)
+}
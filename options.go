@@ -0,0 +1,678 @@
+package hybridlog
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Options holds the logger configuration used by InitWithOptions
+type Options struct {
+	LogDir            string // log directory
+	FileName          string // log file name, may contain {host}, {pid}, {name} (the program) and {date}
+	NoFile            bool   // don't write the main log file, entries only go to the other outputs and hooks
+	MaxSizeMB         int    // max size of log file in MB before it rotates to a new one
+	MaxBackups        int    // max number of rotated log files to keep
+	MaxAgeDays        int    // max age of rotated log files in days
+	MaxTotalSizeMB    int    // max size of all log files of this logger together, the oldest rotated ones are deleted beyond it
+	RetainAcrossDates bool   // apply MaxBackups and MaxAgeDays to the rotated files of all dates, not only the current file's backups
+	Level             int    // 6:Trace, 5:Debug, 4:Info, 3:Warn, 2:Error, 1:Fatal, 0:Panic
+	Compress          bool   // whether to compress rotated log files
+	LevelName         string // log level by name ("debug", "info", ...), overrides Level when set
+
+	RotationInterval  time.Duration   // Daily (default), Hourly, every N hours, Weekly or Monthly
+	RotationLocation  *time.Location  // timezone of the rotation boundary, defaults to time.Local
+	RotateAt          string          // "HH:MM" in RotationLocation periods start at instead of midnight, e.g. "02:00"
+	MaxLines          int             // rotate files before they get more entries than this, 0 for no limit
+	RotationPolicy    RotationPolicy  // rotate files within a period too, e.g. AnyPolicy(DailyAtPolicy(2, 0), SizePolicy(500<<20))
+	RetentionPolicy   RetentionPolicy // delete rotated files it returns, e.g. AnyRetention(AgeRetention(7*24*time.Hour), SizeRetention(5<<30))
+	TimestampLocation *time.Location  // timezone of entry timestamps, defaults to time.Local
+	DatePattern       string          // filename date layout, e.g. "20060102" or "2006/01/02" for subdirectories
+	DirPattern        string          // date layout of the directory files are written to instead, e.g. "2006/01/02" for logDir/2024/05/01/app.log
+	FileNameTemplate  string          // text/template laying out every file name, e.g. "{{.Date}}_{{.Name}}{{.Ext}}", see FileNameData
+	LatestLink        bool            // keep logDir/<FileName> pointing at the current file, e.g. for tail -F
+	Bundle            bool            // pack each past period's files into one tar.gz, e.g. app-2024-05-01.tar.gz
+	DirMode           os.FileMode     // mode of created log directories, defaults to 0755
+	FileMode          os.FileMode     // mode of created log files, defaults to lumberjack's 0600
+	FileGroup         string          // group name or id given to created files and directories
+	EncryptionKeys    KeyProvider     // encrypt log files with AES-GCM, read them with NewDecryptReader
+	HashChain         bool            // sign every line with an HMAC of it and the line before, check files with Verify
+	HashChainKey      []byte          // HMAC key of HashChain, without one it only detects accidental changes
+	SharedFile        bool            // let several processes write the same files, coordinating with a lock file
+	SyncEvery         int             // fsync the log files after every N writes, 1 for each write
+	SyncInterval      time.Duration   // fsync the log files this often when something was written
+
+	Format    Format           // FormatJSON (default), FormatFastJSON, FormatText, FormatLogfmt or FormatECS
+	Formatter logrus.Formatter // custom file formatter, overrides Format
+	Engine    Engine           // encodes entries with zap or zerolog, overrides Format and Formatter
+
+	TimestampFormat string          // time layout of the built-in formats, defaults to time.RFC3339, e.g. time.RFC3339Nano or EpochMillis
+	FieldKeys       logrus.FieldMap // renames keys of the built-in formats, e.g. {"time": "@timestamp", "level": "severity", "msg": "message"}
+	PrettyJSON      bool            // indent the entries of the JSON formats over several lines
+
+	ConsoleOutput    bool             // also write every entry to the console
+	ConsoleWriter    io.Writer        // console destination, defaults to os.Stdout
+	ConsoleFormatter logrus.Formatter // console formatter, defaults to ConsoleFormatter
+
+	SinkLevels map[string]logrus.Level // most verbose level per destination, e.g. "file": Debug, "console": Info, see HybridLogger.SetSinkLevel
+
+	Async                    bool          // queue entries and write them from a background goroutine
+	AsyncBufferSize          int           // queue size in entries, defaults to 1024
+	AsyncFlushInterval       time.Duration // batch writes for up to this long, 0 writes each entry as it arrives
+	AsyncDropWhenFull        bool          // drop entries instead of blocking when the queue is full
+	AsyncDropSummaryInterval time.Duration // how often the number of dropped entries is logged, defaults to 1m
+
+	BufferSize          int           // keep up to this many bytes of writes to the log files in memory, 0 to write each entry
+	BufferFlushInterval time.Duration // how often buffered writes are written out, defaults to 1s
+
+	RingBuffer   int                // keep the last N entries of every level, written to crash-<ts>.log on Panic and Fatal
+	StackTraces  *StackTraceOptions // add a "stack" field to Error and more severe entries
+	ErrorEncoder ErrorEncoder       // structure the errors of entries, e.g. DefaultErrorEncoder
+
+	ContextExtractors []ContextExtractor            // add fields from the context of each entry
+	GlobalFields      logrus.Fields                 // static fields added to every entry, see ServiceFields
+	DynamicFields     map[string]func() interface{} // fields computed for every entry, see HybridLogger.AddDynamicField
+
+	Sampling    map[logrus.Level]SamplingRule   // keep only a sample of the entries of these levels
+	RateLimit   *RateLimitOptions               // cap repeated messages, logging how many were suppressed
+	DedupWindow time.Duration                   // collapse consecutive identical entries, repeats are reported within this long
+	Redact      *RedactOptions                  // mask sensitive fields and values before they are written
+	Filters     []Filter                        // drop entries a filter returns false for
+	Hooks       []logrus.Hook                   // custom hooks, see HybridLogger.AddHook
+	OnError     func(error)                     // called on write and output failures, see HybridLogger.OnError
+	OnRotate    []func(oldPath, newPath string) // called after each rotation, see HybridLogger.OnRotate
+
+	MaxMessageBytes int // cut longer messages and mark the entry truncated=true, 0 for no limit
+	MaxFieldBytes   int // cut longer field values with a string form the same way, 0 for no limit
+
+	FallbackWriter        io.Writer     // where entries go while the log file can't be written, defaults to os.Stderr, none with EncryptionKeys
+	FallbackRetryInterval time.Duration // how often the log file is tried again, defaults to 10s
+	NoFallback            bool          // drop entries instead when the log file can't be written
+
+	ReportCaller bool // add the calling file, line and function to every entry
+	CallerSkip   int  // extra frames to skip when HybridLogger is wrapped again by the caller
+
+	ErrorFile *FileOptions // route Warn and above to this file instead of the main one, named <name>-error<ext> by default
+
+	Syslog *SyslogOptions // mirror entries to syslog
+
+	Journald           bool   // mirror entries to systemd-journald (linux only)
+	JournaldOnly       bool   // send entries to journald instead of the main file
+	JournaldIdentifier string // SYSLOG_IDENTIFIER, defaults to the program name
+
+	Gelf          *GelfOptions          // mirror entries to Graylog
+	Network       *NetworkOptions       // mirror formatted lines to a TCP/UDP collector
+	Loki          *LokiOptions          // push entries to Grafana Loki
+	Elasticsearch *ElasticsearchOptions // index entries into Elasticsearch
+	Fluentd       *FluentdOptions       // forward entries to Fluentd
+	CloudWatch    *CloudWatchOptions    // send entries to AWS CloudWatch Logs
+	CloudLogging  *CloudLoggingOptions  // send entries to Google Cloud Logging, skipped without credentials
+	Splunk        *SplunkOptions        // send entries to a Splunk HTTP Event Collector
+	Webhook       *WebhookOptions       // POST Error and above to a webhook for alerting
+	OTLP          *OTLPOptions          // export entries as OpenTelemetry log records
+
+	Sinks map[string]map[string]interface{} // sinks added with RegisterSink to enable, by name with their config
+
+	Archive   *ArchiveOptions   // upload completed log files to an object store
+	DiskWatch *DiskWatchOptions // purge rotated files when the disk runs low
+
+	HTTPAccess *HTTPAccessOptions // configures HybridLogger.HTTPMiddleware
+	Audit      *AuditOptions      // write audit events to their own file, see HybridLogger.Audit
+}
+
+// FileOptions configures an additional rotating log file
+type FileOptions struct {
+	FileName   string // log file name, dated like the main file, see Options.FileName
+	MaxSizeMB  int    // max size of log file in MB before it rotates to a new one
+	MaxBackups int    // max number of rotated log files to keep
+	MaxAgeDays int    // max age of rotated log files in days
+	Compress   bool   // whether to compress rotated log files
+}
+
+// Option configures Options, used with New
+type Option func(*Options)
+
+// DefaultOptions returns the options New starts from
+func DefaultOptions() Options {
+	return Options{
+		LogDir:     "logs",
+		FileName:   "app.log",
+		MaxSizeMB:  100,
+		MaxBackups: 7,
+		MaxAgeDays: 30,
+		Level:      4,
+	}
+}
+
+// WithLogDir sets the log directory
+func WithLogDir(dir string) Option {
+	return func(o *Options) { o.LogDir = dir }
+}
+
+// WithFileName sets the log file name
+func WithFileName(name string) Option {
+	return func(o *Options) { o.FileName = name }
+}
+
+// WithMaxSize sets the max size of a log file in MB
+func WithMaxSize(mb int) Option {
+	return func(o *Options) { o.MaxSizeMB = mb }
+}
+
+// WithMaxBackups sets the max number of rotated log files to keep
+func WithMaxBackups(n int) Option {
+	return func(o *Options) { o.MaxBackups = n }
+}
+
+// WithMaxAge sets the max age of rotated log files in days
+func WithMaxAge(days int) Option {
+	return func(o *Options) { o.MaxAgeDays = days }
+}
+
+// WithLevel sets the log level, see SetLogLevel
+func WithLevel(level int) Option {
+	return func(o *Options) { o.Level = level }
+}
+
+// WithLevelName sets the log level by name, unknown names fail Init
+func WithLevelName(name string) Option {
+	return func(o *Options) { o.LevelName = name }
+}
+
+// WithCompression sets whether rotated log files are compressed
+func WithCompression(compress bool) Option {
+	return func(o *Options) { o.Compress = compress }
+}
+
+// WithConsoleOutput enables writing every entry to stdout as well as the file
+func WithConsoleOutput(enabled bool) Option {
+	return func(o *Options) { o.ConsoleOutput = enabled }
+}
+
+// WithConsoleWriter sets the console destination (e.g. os.Stderr) and enables console output
+func WithConsoleWriter(w io.Writer) Option {
+	return func(o *Options) {
+		o.ConsoleOutput = true
+		o.ConsoleWriter = w
+	}
+}
+
+// WithConsoleFormatter sets the console formatter, the file keeps its own
+func WithConsoleFormatter(f logrus.Formatter) Option {
+	return func(o *Options) { o.ConsoleFormatter = f }
+}
+
+// WithRotationInterval sets how often a new dated file is started: Daily,
+// Hourly, any whole number of hours that divides a day (e.g. 6*time.Hour),
+// Weekly for ISO weeks or Monthly for calendar months
+func WithRotationInterval(d time.Duration) Option {
+	return func(o *Options) { o.RotationInterval = d }
+}
+
+// WithAsync enables asynchronous writes through a queue of bufferSize entries,
+// batched and written at least every flushInterval
+func WithAsync(bufferSize int, flushInterval time.Duration) Option {
+	return func(o *Options) {
+		o.Async = true
+		o.AsyncBufferSize = bufferSize
+		o.AsyncFlushInterval = flushInterval
+	}
+}
+
+// WithDropWhenFull makes async writes drop entries instead of blocking when the queue is full
+func WithDropWhenFull(drop bool) Option {
+	return func(o *Options) { o.AsyncDropWhenFull = drop }
+}
+
+// WithContextExtractor registers an extractor that adds fields from entry contexts
+func WithContextExtractor(fn ContextExtractor) Option {
+	return func(o *Options) { o.ContextExtractors = append(o.ContextExtractors, fn) }
+}
+
+// WithGlobalFields adds static fields to every entry
+func WithGlobalFields(fields logrus.Fields) Option {
+	return func(o *Options) {
+		if o.GlobalFields == nil {
+			o.GlobalFields = logrus.Fields{}
+		}
+		for k, v := range fields {
+			o.GlobalFields[k] = v
+		}
+	}
+}
+
+// WithCaller enables caller reporting, skip is the number of extra frames to
+// skip for callers that wrap HybridLogger in their own helpers
+func WithCaller(skip int) Option {
+	return func(o *Options) {
+		o.ReportCaller = true
+		o.CallerSkip = skip
+	}
+}
+
+// WithUTC logs timestamps in UTC and rotates at UTC midnight
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
+// WithLocation logs timestamps in loc and computes the rotation boundary in it
+func WithLocation(loc *time.Location) Option {
+	return func(o *Options) {
+		o.RotationLocation = loc
+		o.TimestampLocation = loc
+	}
+}
+
+// WithRotationLocation computes the rotation boundary in loc, leaving timestamps alone
+func WithRotationLocation(loc *time.Location) Option {
+	return func(o *Options) { o.RotationLocation = loc }
+}
+
+// WithDatePattern sets the time layout used for the date in file names. A
+// layout with slashes such as "2006/01/02" writes into date subdirectories
+func WithDatePattern(layout string) Option {
+	return func(o *Options) { o.DatePattern = layout }
+}
+
+// WithFormat selects the built-in formatter for the file output
+func WithFormat(f Format) Option {
+	return func(o *Options) { o.Format = f }
+}
+
+// WithFormatter sets a custom formatter for the file output, overriding WithFormat
+func WithFormatter(f logrus.Formatter) Option {
+	return func(o *Options) { o.Formatter = f }
+}
+
+// WithErrorFile routes Warn, Error, Fatal and Panic entries to a separate
+// file (app-error-<date>.log by default) with its own rotation settings,
+// Info and below stay in the main file
+func WithErrorFile(f FileOptions) Option {
+	return func(o *Options) { o.ErrorFile = &f }
+}
+
+// WithSyslog mirrors every entry to syslog alongside the file
+func WithSyslog(s SyslogOptions) Option {
+	return func(o *Options) { o.Syslog = &s }
+}
+
+// WithJournald sends entries to systemd-journald with their fields as journal
+// fields, in addition to the file or, with replaceFile, instead of it
+func WithJournald(replaceFile bool) Option {
+	return func(o *Options) {
+		o.Journald = true
+		o.JournaldOnly = replaceFile
+	}
+}
+
+// WithGelf mirrors every entry to a Graylog GELF input alongside the file
+func WithGelf(g GelfOptions) Option {
+	return func(o *Options) { o.Gelf = &g }
+}
+
+// WithNetwork mirrors every formatted line to a TCP or UDP collector
+func WithNetwork(n NetworkOptions) Option {
+	return func(o *Options) { o.Network = &n }
+}
+
+// WithLoki pushes every entry to Grafana Loki in batches alongside the file
+func WithLoki(l LokiOptions) Option {
+	return func(o *Options) { o.Loki = &l }
+}
+
+// WithElasticsearch indexes every entry into daily Elasticsearch indices alongside the file
+func WithElasticsearch(e ElasticsearchOptions) Option {
+	return func(o *Options) { o.Elasticsearch = &e }
+}
+
+// WithFluentd forwards every entry to Fluentd over the forward protocol alongside the file
+func WithFluentd(f FluentdOptions) Option {
+	return func(o *Options) { o.Fluentd = &f }
+}
+
+// WithCloudWatch sends every entry to an AWS CloudWatch Logs stream alongside the file
+func WithCloudWatch(c CloudWatchOptions) Option {
+	return func(o *Options) { o.CloudWatch = &c }
+}
+
+// WithCloudLogging sends every entry to Google Cloud Logging alongside the
+// file. Without GCP credentials the output is skipped and only the file is written
+func WithCloudLogging(c CloudLoggingOptions) Option {
+	return func(o *Options) { o.CloudLogging = &c }
+}
+
+// WithSplunk streams every entry to a Splunk HTTP Event Collector alongside the file
+func WithSplunk(sp SplunkOptions) Option {
+	return func(o *Options) { o.Splunk = &sp }
+}
+
+// WithArchive uploads log files to an S3 or GCS bucket once they are rotated
+// out, optionally compressing them first and deleting them afterwards
+func WithArchive(a ArchiveOptions) Option {
+	return func(o *Options) { o.Archive = &a }
+}
+
+// WithWebhook POSTs every Error, Fatal and Panic entry as JSON to a webhook, rate limited
+func WithWebhook(wh WebhookOptions) Option {
+	return func(o *Options) { o.Webhook = &wh }
+}
+
+// WithOTLP exports every entry to an OpenTelemetry collector over OTLP/HTTP alongside the file
+func WithOTLP(ot OTLPOptions) Option {
+	return func(o *Options) { o.OTLP = &ot }
+}
+
+// WithSampling keeps only a sample of the entries of level, e.g.
+// SamplingRule{Every: 100} for Debug, see SampledOut for the dropped counts
+func WithSampling(level logrus.Level, rule SamplingRule) Option {
+	return func(o *Options) {
+		if o.Sampling == nil {
+			o.Sampling = map[logrus.Level]SamplingRule{}
+		}
+		o.Sampling[level] = rule
+	}
+}
+
+// WithRateLimit caps entries with the same message (or RateLimitOptions.Key)
+// to PerSecond, suppressed entries are counted in a periodic summary line
+func WithRateLimit(r RateLimitOptions) Option {
+	return func(o *Options) { o.RateLimit = &r }
+}
+
+// WithDedup collapses consecutive identical entries into the first one plus
+// a copy with a repeat_count field, written when a different entry arrives
+// or at the latest window after the first repeat
+func WithDedup(window time.Duration) Option {
+	return func(o *Options) { o.DedupWindow = window }
+}
+
+// WithRedaction masks the values of the named fields and matches of the
+// patterns in messages and field values, e.g.
+// WithRedaction(RedactOptions{Fields: []string{"password"}, Patterns: []*regexp.Regexp{RedactCreditCards}})
+func WithRedaction(r RedactOptions) Option {
+	return func(o *Options) { o.Redact = &r }
+}
+
+// WithFilter drops the entries f returns false for, e.g. health check requests
+func WithFilter(f Filter) Option {
+	return func(o *Options) { o.Filters = append(o.Filters, f) }
+}
+
+// WithHook adds a custom hook such as Sentry or metrics, see HybridLogger.AddHook
+func WithHook(hook logrus.Hook) Option {
+	return func(o *Options) { o.Hooks = append(o.Hooks, hook) }
+}
+
+// WithOnError sets a callback for write and output failures that logging
+// can't return, see HybridLogger.OnError
+func WithOnError(fn func(error)) Option {
+	return func(o *Options) { o.OnError = fn }
+}
+
+// WithFallback sets where entries go while the log file can't be written
+// (disk full, unwritable directory) and how often the file is tried again.
+// It is ignored with WithEncryption
+func WithFallback(w io.Writer, retry time.Duration) Option {
+	return func(o *Options) {
+		o.FallbackWriter = w
+		o.FallbackRetryInterval = retry
+	}
+}
+
+// WithDiskWatch checks free space in the log dir every interval and deletes
+// the oldest rotated files while less than minFreeMB is left, calling
+// onLowSpace (which may be nil) with the files it removed
+func WithDiskWatch(minFreeMB int64, interval time.Duration, onLowSpace func(freeMB int64, purged []string)) Option {
+	return func(o *Options) {
+		o.DiskWatch = &DiskWatchOptions{MinFreeMB: minFreeMB, Interval: interval, OnLowSpace: onLowSpace}
+	}
+}
+
+// WithMaxTotalSize caps the disk usage of all of the logger's files, current
+// and rotated, deleting the oldest rotated files beyond it
+func WithMaxTotalSize(mb int) Option {
+	return func(o *Options) { o.MaxTotalSizeMB = mb }
+}
+
+// WithOnRotate calls fn after each rotation, see HybridLogger.OnRotate
+func WithOnRotate(fn func(oldPath, newPath string)) Option {
+	return func(o *Options) { o.OnRotate = append(o.OnRotate, fn) }
+}
+
+// WithDirPattern writes each period into its own directory named with the
+// time layout, e.g. "2006/01/02" for logDir/2024/05/01/app.log, instead of
+// adding the date to the file name
+func WithDirPattern(layout string) Option {
+	return func(o *Options) { o.DirPattern = layout }
+}
+
+// WithLatestLink keeps logDir/app.log as a symlink to the current dated file
+// so tail -F app.log follows rotations. On Windows it is a hard link, or a
+// copy refreshed at rotation where hard links aren't supported
+func WithLatestLink() Option {
+	return func(o *Options) { o.LatestLink = true }
+}
+
+// WithRetainAcrossDates applies MaxBackups and MaxAgeDays to the rotated
+// files of every date. Lumberjack only sees the backups of the current
+// dated file, so without it files of past dates are never deleted
+func WithRetainAcrossDates() Option {
+	return func(o *Options) { o.RetainAcrossDates = true }
+}
+
+// WithBundle packs the files of each past rotation period, the dated file
+// and its size-rotated backups, into a single tar.gz once the period ends
+func WithBundle() Option {
+	return func(o *Options) { o.Bundle = true }
+}
+
+// WithPermissions sets the modes of the log directories and files the
+// logger creates, e.g. 0750 and 0640 to let a log shipper in the group read
+// them. Rotated files keep the mode of the file they were
+func WithPermissions(dirMode, fileMode os.FileMode) Option {
+	return func(o *Options) {
+		o.DirMode = dirMode
+		o.FileMode = fileMode
+	}
+}
+
+// WithFileGroup gives the files and directories the logger creates to
+// group, a name or numeric id the process must be a member of
+func WithFileGroup(group string) Option {
+	return func(o *Options) { o.FileGroup = group }
+}
+
+// WithEncryption encrypts the log files with AES-GCM using keys. Read them
+// back with NewDecryptReader or the hybridlog-decrypt command. Entries that
+// can't be written to the files are dropped and reported through OnError,
+// the fallback writer would get them in plaintext
+func WithEncryption(keys KeyProvider) Option {
+	return func(o *Options) { o.EncryptionKeys = keys }
+}
+
+// WithHashChain makes log files tamper-evident: every line carries an HMAC
+// of itself and the previous line's HMAC, which Verify checks
+func WithHashChain(key []byte) Option {
+	return func(o *Options) {
+		o.HashChain = true
+		o.HashChainKey = key
+	}
+}
+
+// WithSharedFile lets several processes log to the same files. Writes and
+// rotations hold an advisory lock on a .<name>.lock file in the log dir,
+// and MaxBackups and MaxAgeDays apply across dates as with
+// WithRetainAcrossDates. It can't be combined with WithHashChain
+func WithSharedFile() Option {
+	return func(o *Options) { o.SharedFile = true }
+}
+
+// WithSync makes log entries survive a power failure by fsyncing the files
+// after every everyN writes and/or every interval, 0 disables either, and
+// before they're closed or rotated. Flush fsyncs them in any case
+func WithSync(everyN int, interval time.Duration) Option {
+	return func(o *Options) {
+		o.SyncEvery = everyN
+		o.SyncInterval = interval
+	}
+}
+
+// WithNonBlocking makes logging never block: entries go through a queue of
+// bufferSize entries and are dropped while it's full. HybridLogger.Dropped
+// counts them and a warning reports the number every minute
+func WithNonBlocking(bufferSize int) Option {
+	return func(o *Options) {
+		o.Async = true
+		o.AsyncBufferSize = bufferSize
+		o.AsyncDropWhenFull = true
+	}
+}
+
+// WithRingBuffer keeps the last size entries in memory, including those
+// below the active level, and dumps them to crash-<timestamp>.log in the log
+// dir on Panic and Fatal entries. Entries of every level are built to keep
+// them, which costs more than skipping debug calls
+func WithRingBuffer(size int) Option {
+	return func(o *Options) { o.RingBuffer = size }
+}
+
+// WithStackTraces adds a "stack" field with up to depth frames to Error,
+// Fatal and Panic entries, starting skip frames below the logging call
+func WithStackTraces(depth, skip int) Option {
+	return func(o *Options) { o.StackTraces = &StackTraceOptions{Depth: depth, Skip: skip} }
+}
+
+// WithErrorEncoder replaces the "error" field of entries with the fields
+// enc returns, DefaultErrorEncoder adds the type, causes and stack
+func WithErrorEncoder(enc ErrorEncoder) Option {
+	return func(o *Options) { o.ErrorEncoder = enc }
+}
+
+// WithoutFile skips the main log file, e.g. for console only logging or
+// tests capturing entries with a hook
+func WithoutFile() Option {
+	return func(o *Options) { o.NoFile = true }
+}
+
+// WithHTTPAccess configures the access logs of HybridLogger.HTTPMiddleware
+func WithHTTPAccess(opts HTTPAccessOptions) Option {
+	return func(o *Options) { o.HTTPAccess = &opts }
+}
+
+// WithAudit enables HybridLogger.Audit, writing events to their own file
+func WithAudit(opts AuditOptions) Option {
+	return func(o *Options) { o.Audit = &opts }
+}
+
+// WithSinkLevel sets the most verbose level written to one destination,
+// such as "file", "console" or "syslog", see HybridLogger.SetSinkLevel
+func WithSinkLevel(name string, level logrus.Level) Option {
+	return func(o *Options) {
+		if o.SinkLevels == nil {
+			o.SinkLevels = map[string]logrus.Level{}
+		}
+		o.SinkLevels[name] = level
+	}
+}
+
+// WithSink enables the sink registered under name, config is passed to its factory
+func WithSink(name string, config map[string]interface{}) Option {
+	return func(o *Options) {
+		if o.Sinks == nil {
+			o.Sinks = map[string]map[string]interface{}{}
+		}
+		o.Sinks[name] = config
+	}
+}
+
+// WithRotationPolicy rotates the log files whenever policy says so, on top
+// of the new file each rotation period starts
+func WithRotationPolicy(policy RotationPolicy) Option {
+	return func(o *Options) { o.RotationPolicy = policy }
+}
+
+// WithRetentionPolicy deletes the rotated files policy returns, checked
+// after each rotation and every minute
+func WithRetentionPolicy(policy RetentionPolicy) Option {
+	return func(o *Options) { o.RetentionPolicy = policy }
+}
+
+// WithBuffer keeps up to size bytes of writes to the log files in memory,
+// writing them out when full and every interval. Flush, Close, Fatal and
+// Panic write out the buffer
+func WithBuffer(size int, interval time.Duration) Option {
+	return func(o *Options) {
+		o.BufferSize = size
+		o.BufferFlushInterval = interval
+	}
+}
+
+// WithDynamicField adds a field computed for every entry, see HybridLogger.AddDynamicField
+func WithDynamicField(name string, fn func() interface{}) Option {
+	return func(o *Options) {
+		if o.DynamicFields == nil {
+			o.DynamicFields = map[string]func() interface{}{}
+		}
+		o.DynamicFields[name] = fn
+	}
+}
+
+// WithRotateAt starts rotation periods at hh:mm instead of midnight, e.g.
+// "02:00" to rotate after the nightly backup. Files are named after the
+// date their period started on
+func WithRotateAt(hhmm string) Option {
+	return func(o *Options) { o.RotateAt = hhmm }
+}
+
+// WithMaxLines rotates the log files before they get more than n entries,
+// whatever their size, for consumers with a limit of records per file
+func WithMaxLines(n int) Option {
+	return func(o *Options) { o.MaxLines = n }
+}
+
+// WithFileNameTemplate lays out the names of all the log files with a
+// text/template executed with FileNameData, e.g. "{{.Host}}.{{.Name}}.{{.Date}}{{.Ext}}"
+// writes app.log as web1.app.2024-05-01.log and its error file as
+// web1.app-error.2024-05-01.log. Without {{.Date}} the date is added before
+// the extension as usual
+func WithFileNameTemplate(text string) Option {
+	return func(o *Options) { o.FileNameTemplate = text }
+}
+
+// WithTimestampFormat sets the time layout of the timestamps the built-in
+// formats write, e.g. time.RFC3339Nano, or EpochMillis for a number of
+// milliseconds. The text format can't write EpochMillis, ECS keeps its own
+func WithTimestampFormat(layout string) Option {
+	return func(o *Options) { o.TimestampFormat = layout }
+}
+
+// WithFieldKeys renames the time, level, msg, func and file keys of the
+// built-in formats other than ECS, e.g. {"time": "@timestamp"}
+func WithFieldKeys(keys logrus.FieldMap) Option {
+	return func(o *Options) { o.FieldKeys = keys }
+}
+
+// WithPrettyJSON indents the entries of the JSON formats over several lines,
+// easier to read but no longer one entry per line
+func WithPrettyJSON() Option {
+	return func(o *Options) { o.PrettyJSON = true }
+}
+
+// WithTruncate cuts messages longer than maxMessage bytes and field values
+// longer than maxField, e.g. 16<<10, adding truncated=true to the entries
+// cut, so a huge payload logged by accident stays a reasonable line. 0 for
+// no limit
+func WithTruncate(maxMessage, maxField int) Option {
+	return func(o *Options) {
+		o.MaxMessageBytes = maxMessage
+		o.MaxFieldBytes = maxField
+	}
+}
+
+// WithEngine encodes the file entries with engine, e.g. hybridlogzap.Engine,
+// overriding WithFormat and WithFormatter
+func WithEngine(engine Engine) Option {
+	return func(o *Options) { o.Engine = engine }
+}
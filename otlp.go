@@ -0,0 +1,197 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OTLPOptions configures the OpenTelemetry OTLP/HTTP logs exporter
+type OTLPOptions struct {
+	Endpoint           string            // collector base URL, defaults to http://localhost:4318
+	Headers            map[string]string // extra request headers, e.g. for authentication
+	ServiceName        string            // service.name resource attribute, defaults to the program name
+	ResourceAttributes map[string]string // other resource attributes such as deployment.environment
+	TraceIDKey         string            // entry field holding the hex trace ID, defaults to "trace_id"
+	SpanIDKey          string            // entry field holding the hex span ID, defaults to "span_id"
+	Batch              BatchOptions
+	Client             *http.Client // defaults to a client with a 10s timeout
+}
+
+// otlpSeverity maps logrus levels to OTel severity numbers and short names
+var otlpSeverity = map[logrus.Level]struct {
+	number int
+	text   string
+}{
+	logrus.TraceLevel: {1, "TRACE"},
+	logrus.DebugLevel: {5, "DEBUG"},
+	logrus.InfoLevel:  {9, "INFO"},
+	logrus.WarnLevel:  {13, "WARN"},
+	logrus.ErrorLevel: {17, "ERROR"},
+	logrus.FatalLevel: {21, "FATAL"},
+	logrus.PanicLevel: {24, "FATAL4"},
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string                 `json:"timeUnixNano"`
+	ObservedTimeUnixNano string                 `json:"observedTimeUnixNano"`
+	SeverityNumber       int                    `json:"severityNumber"`
+	SeverityText         string                 `json:"severityText"`
+	Body                 map[string]interface{} `json:"body"`
+	Attributes           []otlpKeyValue         `json:"attributes,omitempty"`
+	TraceID              string                 `json:"traceId,omitempty"`
+	SpanID               string                 `json:"spanId,omitempty"`
+}
+
+// otlpWriter maps entries to OTel LogRecords and exports them in batches
+type otlpWriter struct {
+	*batcher
+	opts     OTLPOptions
+	url      string
+	resource []otlpKeyValue
+}
+
+func newOTLPWriter(opts OTLPOptions) *otlpWriter {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318"
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = filepath.Base(os.Args[0])
+	}
+	if opts.TraceIDKey == "" {
+		opts.TraceIDKey = "trace_id"
+	}
+	if opts.SpanIDKey == "" {
+		opts.SpanIDKey = "span_id"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resource := []otlpKeyValue{{Key: "service.name", Value: otlpValue(opts.ServiceName)}}
+	keys := make([]string, 0, len(opts.ResourceAttributes))
+	for k := range opts.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		resource = append(resource, otlpKeyValue{Key: k, Value: otlpValue(opts.ResourceAttributes[k])})
+	}
+
+	o := &otlpWriter{
+		opts:     opts,
+		url:      strings.TrimRight(opts.Endpoint, "/") + "/v1/logs",
+		resource: resource,
+	}
+	o.batcher = newBatcher(opts.Batch, o.send)
+	return o
+}
+
+// WriteEntry queues the entry encoded as an OTLP JSON LogRecord
+func (o *otlpWriter) WriteEntry(e *logrus.Entry) error {
+	rec := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(e.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity[e.Level].number,
+		SeverityText:         otlpSeverity[e.Level].text,
+		Body:                 otlpValue(e.Message),
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := e.Data[k]
+		switch k {
+		case o.opts.TraceIDKey:
+			rec.TraceID = fmt.Sprint(v)
+			continue
+		case o.opts.SpanIDKey:
+			rec.SpanID = fmt.Sprint(v)
+			continue
+		}
+		rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: k, Value: otlpValue(v)})
+	}
+	if e.HasCaller() {
+		rec.Attributes = append(rec.Attributes,
+			otlpKeyValue{Key: "code.function", Value: otlpValue(e.Caller.Function)},
+			otlpKeyValue{Key: "code.filepath", Value: otlpValue(e.Caller.File)},
+			otlpKeyValue{Key: "code.lineno", Value: otlpValue(e.Caller.Line)},
+		)
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return o.enqueue(batchRecord{time: e.Time, level: e.Level, line: b})
+}
+
+// otlpValue converts a field value to an OTLP AnyValue
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return map[string]interface{}{"intValue": fmt.Sprint(v)}
+	case float32:
+		return map[string]interface{}{"doubleValue": float64(v)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case error:
+		return map[string]interface{}{"stringValue": v.Error()}
+	case fmt.Stringer:
+		return map[string]interface{}{"stringValue": v.String()}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+func (o *otlpWriter) send(batch []batchRecord) error {
+	records := make([]json.RawMessage, len(batch))
+	for i, rec := range batch {
+		records[i] = rec.line
+	}
+	payload := map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": o.resource},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]string{"name": "github.com/git4rakesh/hybrid_log"},
+				"logRecords": records,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	return doHTTP(o.opts.Client, req)
+}
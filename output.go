@@ -0,0 +1,231 @@
+package hybridlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// output is a secondary destination mirrored from the log file, rendered
+// with its own formatter or the file formatter when nil
+type output struct {
+	name      string // sink name for SetSinkLevel, such as "console"
+	w         io.Writer
+	formatter logrus.Formatter
+	level     logrus.Level // most verbose level written
+	owned     bool         // created by the logger, closed by Close
+}
+
+// levelWriter is implemented by outputs that need the entry level, such as
+// syslog which maps it to a severity
+type levelWriter interface {
+	WriteLevel(level logrus.Level, p []byte) (int, error)
+}
+
+// entryWriter is implemented by outputs that encode the entry themselves,
+// such as journald which keeps fields as journal fields
+type entryWriter interface {
+	WriteEntry(e *logrus.Entry) error
+}
+
+// teeFormatter is installed as the logrus formatter. It renders the entry for
+// every secondary output and returns the bytes for the log file
+type teeFormatter struct {
+	h *HybridLogger
+}
+
+func (t *teeFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if isDropped(e) {
+		return nil, nil
+	}
+	t.h.metrics.entries[e.Level].Add(1)
+	if e.Level <= logrus.FatalLevel {
+		t.h.unbuffer() // it must reach the file before the program ends
+	}
+
+	t.h.outMu.RLock()
+	outputs, formatter := t.h.outputs, t.h.formatter
+	t.h.outMu.RUnlock()
+
+	// Formatters render into e.Buffer when set, keep it for the file
+	buf := e.Buffer
+	toErrorFile := false
+	for _, o := range outputs {
+		if e.Level > o.level {
+			continue
+		}
+		toErrorFile = toErrorFile || o.name == "error_file"
+		if ew, ok := o.w.(entryWriter); ok {
+			if err := ew.WriteEntry(e); err != nil {
+				t.h.reportError(err)
+			}
+			continue
+		}
+		f := o.formatter
+		if f == nil {
+			f = formatter
+		}
+		e.Buffer = nil
+		b, err := f.Format(e)
+		if err == nil {
+			// best effort, the file is the source of truth
+			if lw, ok := o.w.(levelWriter); ok {
+				_, err = lw.WriteLevel(e.Level, b)
+			} else {
+				_, err = o.w.Write(b)
+			}
+		}
+		if err != nil {
+			t.h.reportError(err)
+		}
+	}
+	e.Buffer = buf
+
+	// Entries written to the error file are left out of the main file
+	if toErrorFile || t.h.noFile || e.Level > logrus.Level(t.h.fileLevel.Load()) {
+		return nil, nil
+	}
+	return formatter.Format(e)
+}
+
+// dropKey marks an entry a hook decided not to write, see dropEntry
+const dropKey = "\x00hybridlog.drop"
+
+// dropEntry makes the logger discard e, hooks cannot stop an entry
+// themselves so they mark it for teeFormatter
+func dropEntry(e *logrus.Entry) {
+	e.Data[dropKey] = true
+}
+
+func isDropped(e *logrus.Entry) bool {
+	_, ok := e.Data[dropKey]
+	return ok
+}
+
+// addOutput mirrors entries to another destination
+func (h *HybridLogger) addOutput(o *output) {
+	if r, ok := o.w.(errorReporter); ok {
+		r.setErrorHandler(h.reportError)
+	}
+
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+
+	outputs := make([]*output, len(h.outputs), len(h.outputs)+1)
+	copy(outputs, h.outputs)
+	h.outputs = append(outputs, o)
+}
+
+// SetSinkLevel sets the most verbose level written to one destination:
+// "file" for the main log file or "console", "error_file", "syslog",
+// "journald", "gelf", "network", "loki", "elasticsearch", "fluentd",
+// "cloudwatch", "cloud_logging", "splunk", "webhook" and "otlp" when
+// enabled. The level of the logger applies first, it has to be at least as
+// verbose as the most verbose sink
+func (h *HybridLogger) SetSinkLevel(name string, level logrus.Level) error {
+	if name == "file" {
+		h.fileLevel.Store(uint32(level))
+		return nil
+	}
+
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+
+	for i, o := range h.outputs {
+		if o.name == name {
+			outputs := append([]*output(nil), h.outputs...) // entries may be writing the old ones
+			changed := *o
+			changed.level = level
+			outputs[i] = &changed
+			h.outputs = outputs
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sink %q", name)
+}
+
+// SinkLevels returns the level of the main file and every named output
+func (h *HybridLogger) SinkLevels() map[string]logrus.Level {
+	h.outMu.RLock()
+	defer h.outMu.RUnlock()
+
+	levels := map[string]logrus.Level{"file": logrus.Level(h.fileLevel.Load())}
+	for _, o := range h.outputs {
+		if o.name != "" {
+			levels[o.name] = o.level
+		}
+	}
+	return levels
+}
+
+// flushOutputs flushes outputs that buffer entries, such as batching network outputs
+func (h *HybridLogger) flushOutputs() error {
+	h.outMu.RLock()
+	outputs := h.outputs
+	h.outMu.RUnlock()
+
+	var firstErr error
+	for _, o := range outputs {
+		if f, ok := o.w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// closeOutputs closes the outputs created by the logger
+func (h *HybridLogger) closeOutputs() error {
+	h.outMu.RLock()
+	outputs := h.outputs
+	h.outMu.RUnlock()
+
+	var firstErr error
+	for _, o := range outputs {
+		if c, ok := o.w.(io.Closer); ok && o.owned {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// SetFileFormatter sets the formatter of the log file output at runtime, any
+// logrus.Formatter works, e.g. a bespoke pipe-delimited legacy format
+func (h *HybridLogger) SetFileFormatter(formatter logrus.Formatter) {
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+
+	h.formatter = formatter
+}
+
+// SetFormatter is SetFileFormatter. It replaces logrus.Logger.SetFormatter,
+// which would bypass the console and other outputs
+func (h *HybridLogger) SetFormatter(formatter logrus.Formatter) {
+	h.SetFileFormatter(formatter)
+}
+
+// ConsoleFormatter returns a human friendly text formatter with short
+// timestamps, colored by level when w is a terminal
+func ConsoleFormatter(w io.Writer) logrus.Formatter {
+	color := isTerminal(w)
+	return &logrus.TextFormatter{
+		ForceColors:     color,
+		DisableColors:   !color,
+		FullTimestamp:   true,
+		TimestampFormat: "15:04:05.000",
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,98 @@
+package hybridlog
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// filePerm holds the modes and group of the files and directories the
+// logger creates, zero values keep lumberjack's 0600 files and 0755 dirs
+type filePerm struct {
+	dirMode  os.FileMode
+	fileMode os.FileMode
+	gid      int // -1 keeps the process' group
+}
+
+// lookupGroup resolves a group name or id, "" gives -1
+func lookupGroup(group string) (int, error) {
+	if group == "" {
+		return -1, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		var idErr error
+		if g, idErr = user.LookupGroupId(group); idErr != nil {
+			return -1, fmt.Errorf("failed to look up log file group: %v", err)
+		}
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("group %q has no numeric id", group)
+	}
+	return gid, nil
+}
+
+// mkdirAll creates dir and its missing parents with the configured mode
+// and group
+func (p filePerm) mkdirAll(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := p.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	mode := p.dirMode
+	if mode == 0 {
+		mode = 0755
+	}
+	if err := os.Mkdir(dir, mode); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	return p.apply(dir, p.dirMode)
+}
+
+// create creates an empty file for lumberjack to append to, which
+// otherwise creates it with mode 0600
+func (p filePerm) create(name string) error {
+	if p.fileMode == 0 && p.gid < 0 {
+		return nil
+	}
+	if err := p.mkdirAll(filepath.Dir(name)); err != nil {
+		return err
+	}
+	mode := p.fileMode
+	if mode == 0 {
+		mode = 0600
+	}
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	file.Close()
+	return p.apply(name, p.fileMode)
+}
+
+// apply sets mode, unless 0, and the group on path. Chmod isn't limited
+// by the umask like the mode files are created with
+func (p filePerm) apply(path string, mode os.FileMode) error {
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	if p.gid >= 0 {
+		return os.Chown(path, -1, p.gid)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package hybridlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// RotationPolicy decides when a log file is rotated, on top of the new file
+// each rotation period starts and lumberjack's MaxSizeMB. It is asked
+// before every write to a file that isn't empty, a rotation keeps the
+// current file as a backup like Rotate
+type RotationPolicy interface {
+	ShouldRotate(s RotationState) bool
+}
+
+// RotationState describes the current file to a RotationPolicy
+type RotationState struct {
+	Now      time.Time
+	Started  time.Time // when the file was started, for a file found on startup when it was last written
+	Size     int64     // bytes in the file, 0 with SharedFile where other processes write too
+	Lines    int64     // lines in the file, only those written since it was found on startup unless MaxLines is set
+	Next     int       // size of the write about to be made
+	NewLines int64     // lines in that write
+}
+
+// RotationPolicyFunc is a RotationPolicy implemented by a function
+type RotationPolicyFunc func(s RotationState) bool
+
+func (f RotationPolicyFunc) ShouldRotate(s RotationState) bool { return f(s) }
+
+// SizePolicy rotates before a write would take the file over maxBytes
+func SizePolicy(maxBytes int64) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		return s.Size+int64(s.Next) > maxBytes
+	})
+}
+
+// AgePolicy rotates files that were started more than d ago
+func AgePolicy(d time.Duration) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		return s.Now.Sub(s.Started) >= d
+	})
+}
+
+// DailyAtPolicy rotates once a day at hour:minute local time, e.g. 02:00
+func DailyAtPolicy(hour, minute int) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		y, m, d := s.Now.Date()
+		at := time.Date(y, m, d, hour, minute, 0, 0, s.Now.Location())
+		if at.After(s.Now) {
+			at = at.AddDate(0, 0, -1)
+		}
+		return s.Started.Before(at)
+	})
+}
+
+// LinePolicy rotates before a write would take the file over maxLines
+// lines, a write of more lines than that still goes to one file
+func LinePolicy(maxLines int64) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		return s.Lines+s.NewLines > maxLines
+	})
+}
+
+// AnyPolicy rotates when any of policies does, e.g. at 02:00 and at 500MB:
+//
+//	AnyPolicy(DailyAtPolicy(2, 0), SizePolicy(500<<20))
+func AnyPolicy(policies ...RotationPolicy) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		for _, p := range policies {
+			if p.ShouldRotate(s) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AllPolicy rotates when every one of policies does, e.g. files older than
+// an hour once they have 1MB
+func AllPolicy(policies ...RotationPolicy) RotationPolicy {
+	return RotationPolicyFunc(func(s RotationState) bool {
+		for _, p := range policies {
+			if !p.ShouldRotate(s) {
+				return false
+			}
+		}
+		return len(policies) > 0
+	})
+}
+
+// checkPolicy rotates the file if its policy says so, with f.mu held
+func (f *rotatingFile) checkPolicy(next int, lines int64) {
+	if f.policy == nil || f.size == 0 && f.lines == 0 {
+		return
+	}
+	s := RotationState{Now: time.Now(), Started: f.started, Size: f.size, Lines: f.lines, Next: next, NewLines: lines}
+	if f.shared != nil {
+		s.Size = 0
+	}
+	if !f.policy.ShouldRotate(s) {
+		return
+	}
+	if err := f.rotateLocked(); err != nil && f.report != nil {
+		f.report(err)
+	}
+}
+
+// RetentionPolicy decides which rotated files are deleted. It is asked
+// after each rotation and every minute, on top of MaxBackups, MaxAgeDays
+// and MaxTotalSizeMB, and returns the paths to delete
+type RetentionPolicy interface {
+	Expired(s RetentionState) []string
+}
+
+// RetentionState describes the rotated files of the logger to a RetentionPolicy
+type RetentionState struct {
+	Now       time.Time
+	Files     []RetainedFile // newest first
+	InUse     int64          // bytes in the files being written, which are never deleted
+	DiskFree  uint64         // bytes available in the log dir, 0 with DiskTotal when unknown
+	DiskTotal uint64
+}
+
+// RetainedFile is a rotated log file
+type RetainedFile struct {
+	Path    string
+	File    string // name of the logger's file it was rotated from, e.g. "app.log"
+	Size    int64
+	ModTime time.Time
+}
+
+// RetentionPolicyFunc is a RetentionPolicy implemented by a function
+type RetentionPolicyFunc func(s RetentionState) []string
+
+func (f RetentionPolicyFunc) Expired(s RetentionState) []string { return f(s) }
+
+// AgeRetention deletes files last written more than d ago
+func AgeRetention(d time.Duration) RetentionPolicy {
+	return RetentionPolicyFunc(func(s RetentionState) []string {
+		var paths []string
+		for _, f := range s.Files {
+			if s.Now.Sub(f.ModTime) > d {
+				paths = append(paths, f.Path)
+			}
+		}
+		return paths
+	})
+}
+
+// CountRetention keeps the newest n files
+func CountRetention(n int) RetentionPolicy {
+	return RetentionPolicyFunc(func(s RetentionState) []string {
+		var paths []string
+		for i := n; i < len(s.Files); i++ {
+			paths = append(paths, s.Files[i].Path)
+		}
+		return paths
+	})
+}
+
+// SizeRetention deletes the oldest files while all of them, with the files
+// being written, take more than maxBytes
+func SizeRetention(maxBytes int64) RetentionPolicy {
+	return RetentionPolicyFunc(func(s RetentionState) []string {
+		total := s.InUse
+		for _, f := range s.Files {
+			total += f.Size
+		}
+		var paths []string
+		for i := len(s.Files) - 1; i >= 0 && total > maxBytes; i-- {
+			paths = append(paths, s.Files[i].Path)
+			total -= s.Files[i].Size
+		}
+		return paths
+	})
+}
+
+// FreeDiskRetention deletes the oldest files while less than percent of the
+// disk holding the log dir is free
+func FreeDiskRetention(percent float64) RetentionPolicy {
+	return RetentionPolicyFunc(func(s RetentionState) []string {
+		if s.DiskTotal == 0 {
+			return nil
+		}
+		min := uint64(percent / 100 * float64(s.DiskTotal))
+		free := s.DiskFree
+		var paths []string
+		for i := len(s.Files) - 1; i >= 0 && free < min; i-- {
+			paths = append(paths, s.Files[i].Path)
+			free += uint64(s.Files[i].Size)
+		}
+		return paths
+	})
+}
+
+// AnyRetention deletes the files any of policies does, so that all of their
+// limits hold, e.g. keep 7 days or 5GB whichever is smaller:
+//
+//	AnyRetention(AgeRetention(7*24*time.Hour), SizeRetention(5<<30))
+func AnyRetention(policies ...RetentionPolicy) RetentionPolicy {
+	return RetentionPolicyFunc(func(s RetentionState) []string {
+		seen := map[string]bool{}
+		var paths []string
+		for _, p := range policies {
+			for _, path := range p.Expired(s) {
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+			}
+		}
+		return paths
+	})
+}
+
+// countLines returns the number of lines in the file at path
+func countLines(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var lines int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := file.Read(buf)
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
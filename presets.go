@@ -0,0 +1,40 @@
+package hybridlog
+
+import (
+	"os"
+	"time"
+)
+
+// Dev is a preset for development: colored text on stdout when it is a
+// terminal, debug entries included and no log file. Options after it in
+// New still apply, e.g. New(Dev(), WithLevel(6)). With NoFile turned back
+// off the file is written uncompressed as indented JSON
+func Dev() Option {
+	return func(o *Options) {
+		o.Level = 5
+		o.LevelName = ""
+		o.NoFile = true
+		o.Compress = false
+		o.PrettyJSON = true
+		o.ConsoleOutput = true
+		o.ConsoleWriter = os.Stdout
+	}
+}
+
+// Production is a preset for files read by a log pipeline: one JSON entry
+// per line written by FastJSONFormatter with nanosecond timestamps, info
+// level, no console output, and rotated files compressed and deleted after
+// MaxBackups/MaxAgeDays whatever their date
+func Production() Option {
+	return func(o *Options) {
+		o.Level = 4
+		o.LevelName = ""
+		o.NoFile = false
+		o.Format = FormatFastJSON
+		o.TimestampFormat = time.RFC3339Nano
+		o.PrettyJSON = false
+		o.Compress = true
+		o.RetainAcrossDates = true
+		o.ConsoleOutput = false
+	}
+}
@@ -0,0 +1,141 @@
+package hybridlog
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitOptions caps how often entries with the same key are written
+type RateLimitOptions struct {
+	PerSecond       float64                      // entries per second per key
+	Burst           int                          // entries written at once before the rate applies, defaults to PerSecond rounded up
+	Key             func(e *logrus.Entry) string // groups entries, defaults to the message
+	SummaryInterval time.Duration                // how often suppressed counts are logged, defaults to 1m
+}
+
+type rateBucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+// rateLimitSummary marks the context of summary entries so they are never limited
+type rateLimitSummary struct{}
+
+// rateLimitHook drops entries whose key ran out of tokens and periodically
+// logs how many were suppressed
+type rateLimitHook struct {
+	opts    RateLimitOptions
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newRateLimitHook(opts RateLimitOptions) *rateLimitHook {
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Ceil(opts.PerSecond))
+	}
+	if opts.Key == nil {
+		opts.Key = func(e *logrus.Entry) string { return e.Message }
+	}
+	if opts.SummaryInterval <= 0 {
+		opts.SummaryInterval = time.Minute
+	}
+	return &rateLimitHook{
+		opts:    opts,
+		buckets: map[string]*rateBucket{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+func (r *rateLimitHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (r *rateLimitHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	if e.Context != nil && e.Context.Value(rateLimitSummary{}) != nil {
+		return nil
+	}
+	key := r.opts.Key(e)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: float64(r.opts.Burst), last: now}
+		r.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * r.opts.PerSecond
+	if b.tokens > float64(r.opts.Burst) {
+		b.tokens = float64(r.opts.Burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		b.suppressed++
+		dropEntry(e)
+		return nil
+	}
+	b.tokens--
+	return nil
+}
+
+// run logs the summaries every SummaryInterval until Close
+func (r *rateLimitHook) run(l *logrus.Logger) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.SummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.summarize(l)
+		case <-r.stop:
+			r.summarize(l)
+			return
+		}
+	}
+}
+
+// summarize logs one line per key with suppressed entries and forgets idle keys
+func (r *rateLimitHook) summarize(l *logrus.Logger) {
+	now := time.Now()
+	suppressed := map[string]int{}
+
+	r.mu.Lock()
+	for key, b := range r.buckets {
+		if b.suppressed > 0 {
+			suppressed[key] = b.suppressed
+			b.suppressed = 0
+		} else if now.Sub(b.last) > r.opts.SummaryInterval {
+			delete(r.buckets, key)
+		}
+	}
+	r.mu.Unlock()
+
+	period := r.opts.SummaryInterval.String()
+	if r.opts.SummaryInterval == time.Minute {
+		period = "minute"
+	}
+	ctx := context.WithValue(context.Background(), rateLimitSummary{}, true)
+	for key, n := range suppressed {
+		l.WithContext(ctx).WithFields(logrus.Fields{"rate_limit_key": key, "suppressed": n}).
+			Warnf("suppressed %d duplicates in the last %s", n, period)
+	}
+}
+
+// Close logs the pending summaries and stops the background goroutine
+func (r *rateLimitHook) Close() {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+}
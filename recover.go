@@ -0,0 +1,41 @@
+package hybridlog
+
+import (
+	"runtime/debug"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecoverAndLog recovers a panic, logs it at error level with the stack
+// trace and flushes the logs so the last entries aren't lost. Use it
+// deferred, the goroutine carries on after the deferred call:
+//
+//	defer log.RecoverAndLog()
+func (h *HybridLogger) RecoverAndLog() {
+	if v := recover(); v != nil {
+		h.logPanic(v, debug.Stack())
+	}
+}
+
+// RecoverLogAndRepanic is RecoverAndLog panicking again with the same value
+// once the logs are flushed, for panics that should still crash the program
+func (h *HybridLogger) RecoverLogAndRepanic() {
+	if v := recover(); v != nil {
+		h.logPanic(v, debug.Stack())
+		panic(v)
+	}
+}
+
+func (h *HybridLogger) logPanic(v interface{}, stack []byte) {
+	entry := h.WithFields(logrus.Fields{"panic": v, "stack": string(stack)})
+	if err, ok := v.(error); ok {
+		entry = entry.WithError(err)
+	}
+	entry.Errorf("recovered panic: %v", v)
+	if h.ring != nil {
+		if _, err := h.DumpRecent(); err != nil {
+			h.reportError(err)
+		}
+	}
+	h.Flush()
+}
@@ -0,0 +1,173 @@
+package hybridlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Patterns for common sensitive values, for use in RedactOptions.Patterns
+var (
+	RedactCreditCards  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	RedactEmails       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	RedactBearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`)
+)
+
+// RedactOptions configures masking of sensitive values before entries are
+// written to any output
+type RedactOptions struct {
+	Fields   []string         // field names whose values are masked entirely, case-insensitive and at any depth, e.g. "password"
+	Patterns []*regexp.Regexp // matches in messages and string values at any depth are masked
+	Mask     string           // replacement, defaults to "[REDACTED]"
+}
+
+// redactor holds the redaction rules, replaced as a whole when they change
+type redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	replace  string
+}
+
+func newRedactor(opts RedactOptions) *redactor {
+	r := &redactor{fields: map[string]bool{}, replace: opts.Mask}
+	if r.replace == "" {
+		r.replace = "[REDACTED]"
+	}
+	for _, f := range opts.Fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	r.patterns = append(r.patterns, opts.Patterns...)
+	return r
+}
+
+// sensitive reports whether the field is masked by name, a dotted key such
+// as "user.password" matches on its last part
+func (r *redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	if r.fields[key] {
+		return true
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return r.fields[key[i+1:]]
+	}
+	return false
+}
+
+func (r *redactor) mask(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllLiteralString(s, r.replace)
+	}
+	return s
+}
+
+// value masks pattern matches in v. Maps, structs and slices are masked in
+// their JSON form, by field name and pattern at every depth, and replaced by
+// it when something was masked. Other values are left alone
+func (r *redactor) value(v interface{}) interface{} {
+	var s string
+	switch v := v.(type) {
+	case string:
+		return r.mask(v)
+	case []byte:
+		s = string(v)
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	case int, int64, uint64:
+		s = fmt.Sprint(v)
+	default:
+		if !isComposite(v) {
+			return v
+		}
+		return r.composite(v)
+	}
+	if masked := r.mask(s); masked != s {
+		return masked
+	}
+	return v
+}
+
+// composite masks v in its JSON form, numbers keep their exact encoding
+func (r *redactor) composite(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return v
+	}
+	if masked, changed := r.walk(decoded); changed {
+		return masked
+	}
+	return v
+}
+
+// walk masks sensitive fields and pattern matches in a decoded JSON value,
+// reporting whether anything was masked
+func (r *redactor) walk(v interface{}) (interface{}, bool) {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			if r.sensitive(k) {
+				v[k], changed = r.replace, true
+			} else if masked, ok := r.walk(x); ok {
+				v[k], changed = masked, true
+			}
+		}
+	case []interface{}:
+		for i, x := range v {
+			if masked, ok := r.walk(x); ok {
+				v[i], changed = masked, true
+			}
+		}
+	case string:
+		if masked := r.mask(v); masked != v {
+			return masked, true
+		}
+	}
+	return v, changed
+}
+
+// redactHook masks sensitive fields and values, it runs after the hooks that
+// add fields so those are covered too
+type redactHook struct {
+	c *core
+}
+
+func (r *redactHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (r *redactHook) Fire(e *logrus.Entry) error {
+	r.c.redactMu.RLock()
+	red := r.c.redactor
+	r.c.redactMu.RUnlock()
+
+	if red == nil {
+		return nil
+	}
+	for k, v := range e.Data {
+		if red.sensitive(k) {
+			e.Data[k] = red.replace
+		} else {
+			e.Data[k] = red.value(v)
+		}
+	}
+	e.Message = red.mask(e.Message)
+	return nil
+}
+
+// Redact replaces the redaction rules at runtime
+func (h *HybridLogger) Redact(opts RedactOptions) {
+	h.redactMu.Lock()
+	defer h.redactMu.Unlock()
+
+	h.redactor = newRedactor(opts)
+}
@@ -0,0 +1,67 @@
+package hybridlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactNestedValues(t *testing.T) {
+	type account struct {
+		Owner    string `json:"owner"`
+		Password string `json:"password"`
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		leak  string
+	}{
+		{"string", "card 4111 1111 1111 1111", "4111"},
+		{"bytes", []byte("card 4111 1111 1111 1111"), "4111"},
+		{"nested map key", map[string]interface{}{"user": map[string]interface{}{"password": "hunter2"}}, "hunter2"},
+		{"nested map pattern", map[string]interface{}{"user": map[string]interface{}{"card": "4111 1111 1111 1111"}}, "4111"},
+		{"slice", []string{"4111 1111 1111 1111", "5500 0000 0000 0004"}, "5500"},
+		{"struct", account{Owner: "alice", Password: "hunter2"}, "hunter2"},
+		{"pointer to struct", &account{Owner: "alice", Password: "hunter2"}, "hunter2"},
+		{"error causes", DefaultErrorEncoder(fmt.Errorf("charge: %w", errors.New("card 4111 1111 1111 1111 declined")))["error.causes"], "4111"},
+	}
+	hook := &redactHook{c: &core{redactor: newRedactor(RedactOptions{
+		Fields:   []string{"password"},
+		Patterns: []*regexp.Regexp{RedactCreditCards},
+	})}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := logrus.NewEntry(logrus.New()).WithField("v", tt.value)
+			if err := hook.Fire(e); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(e.Data["v"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(b), tt.leak) {
+				t.Fatalf("%s leaked in %s", tt.leak, b)
+			}
+			if !strings.Contains(string(b), "[REDACTED]") {
+				t.Fatalf("nothing masked in %s", b)
+			}
+		})
+	}
+}
+
+func TestRedactKeepsCleanValues(t *testing.T) {
+	hook := &redactHook{c: &core{redactor: newRedactor(RedactOptions{Fields: []string{"password"}})}}
+	value := map[string]int{"count": 1}
+	e := logrus.NewEntry(logrus.New()).WithField("v", value)
+	if err := hook.Fire(e); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.Data["v"].(map[string]int); !ok {
+		t.Fatalf("%T replaced although nothing was masked", e.Data["v"])
+	}
+}
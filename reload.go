@@ -0,0 +1,181 @@
+package hybridlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// configSettings tells which of the runtime settings a config file set
+type configSettings struct {
+	sampling bool
+	redact   bool
+	limits   [3]bool // max_backups, max_age_days and max_total_size_mb
+	base     [3]int  // those limits before a config set them
+}
+
+// ApplyConfig applies the settings of cfg that can change at runtime: the
+// level, sampling, redaction and the retention of the main file. Sampling,
+// redaction and each retention limit left out of cfg are reset only when an
+// earlier config set them, those set in code stay. The other settings only take
+// effect on the next Init
+func (h *HybridLogger) ApplyConfig(cfg Config) error {
+	opts, err := cfg.Options()
+	if err != nil {
+		return err
+	}
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	if opts.LevelName != "" {
+		if err := h.SetLogLevelString(opts.LevelName); err != nil {
+			return err
+		}
+	}
+	prev := h.fromConfig
+	set := configSettings{
+		sampling: len(opts.Sampling) > 0,
+		redact:   opts.Redact != nil,
+		base:     prev.base,
+	}
+	if set.sampling || prev.sampling {
+		h.SetSampling(opts.Sampling)
+	}
+	if set.redact {
+		h.Redact(*opts.Redact)
+	} else if prev.redact {
+		h.redactMu.Lock()
+		h.redactor = nil
+		h.redactMu.Unlock()
+	}
+	cur := h.retentionLimits()
+	next := cur
+	for i, v := range [3]*int{cfg.MaxBackups, cfg.MaxAgeDays, cfg.MaxTotalSizeMB} {
+		switch {
+		case v != nil:
+			if !prev.limits[i] {
+				set.base[i] = cur[i]
+			}
+			set.limits[i] = true
+			next[i] = *v
+		case prev.limits[i]:
+			next[i] = prev.base[i]
+		}
+	}
+	if next != cur {
+		h.SetRetention(next[0], next[1], next[2])
+	}
+	h.fromConfig = set
+	return nil
+}
+
+// WatchConfig reloads the config file at path with ApplyConfig whenever it
+// changes. On Linux changes are picked up through inotify, elsewhere, or if
+// inotify is unavailable, the file is checked every interval (5s by
+// default). A file that fails to load is reported through OnError and the
+// previous settings stay. Calling it again watches the new path instead
+func (h *HybridLogger) WatchConfig(path string, interval time.Duration) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to watch config: %v", err)
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	w := &configWatcher{
+		h:        h,
+		path:     path,
+		interval: interval,
+		mod:      info.ModTime(),
+		size:     info.Size(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if events, closer, err := watchDir(filepath.Dir(path)); err == nil {
+		w.events, w.closer = events, closer
+	}
+
+	h.mu.Lock()
+	old := h.watcher
+	h.watcher = w
+	h.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	go w.run()
+	return nil
+}
+
+// configWatcher checks a config file when its directory reports a change,
+// or polls it. The modification time and size tell when it changed
+type configWatcher struct {
+	h        *HybridLogger
+	path     string
+	interval time.Duration
+	mod      time.Time
+	size     int64
+	events   <-chan struct{} // nil when polling
+	closer   io.Closer       // stops events
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (w *configWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	if w.events != nil {
+		ticker.Stop()
+	}
+
+	for {
+		select {
+		case _, ok := <-w.events:
+			if !ok {
+				w.events = nil // the watch failed, poll instead
+				ticker.Reset(w.interval)
+				continue
+			}
+			w.check()
+		case <-ticker.C:
+			w.check()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *configWatcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil || info.ModTime().Equal(w.mod) && info.Size() == w.size {
+		return // being replaced, or unchanged
+	}
+	w.mod, w.size = info.ModTime(), info.Size()
+
+	cfg, err := LoadConfig(w.path)
+	if err == nil {
+		err = w.h.ApplyConfig(cfg)
+	}
+	if err != nil {
+		w.h.reportError(fmt.Errorf("failed to reload config: %v", err))
+		return
+	}
+	w.h.Infof("reloaded log config from %s", w.path)
+}
+
+// Close stops watching
+func (w *configWatcher) Close() {
+	w.once.Do(func() {
+		close(w.stop)
+		if w.closer != nil {
+			w.closer.Close()
+		}
+	})
+	<-w.done
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package hybridlog
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchDir watches dir with inotify, the channel receives after files in it
+// are written or moved in. The directory rather than the file is watched as
+// editors and config management replace files by renaming over them
+func watchDir(dir string) (<-chan struct{}, io.Closer, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := unix.InotifyAddWatch(fd, dir, unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO); err != nil {
+		unix.Close(fd)
+		return nil, nil, err
+	}
+	// A non-blocking fd goes through the runtime poller, so closing the
+	// file ends a pending Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default: // a check is pending already
+			}
+		}
+	}()
+	return events, f, nil
+}
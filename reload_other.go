@@ -0,0 +1,12 @@
+//go:build !linux
+
+package hybridlog
+
+import (
+	"errors"
+	"io"
+)
+
+func watchDir(dir string) (<-chan struct{}, io.Closer, error) {
+	return nil, nil, errors.ErrUnsupported
+}
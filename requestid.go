@@ -0,0 +1,64 @@
+package hybridlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is the header HTTPMiddleware reads correlation IDs from
+// and echoes them in, unless HTTPAccessOptions.RequestIDHeader says otherwise
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// crockford is the base32 alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewRequestID returns a new correlation ID, a ULID: 26 characters that
+// sort by creation time, to the millisecond
+func NewRequestID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	var out [26]byte
+	// 128 bits in 26 groups of 5, the first one only has 3
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the correlation ID
+// id, it is added as "request_id" to every entry logged with that context
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	ctx = ContextWithFields(ctx, logrus.Fields{"request_id": id})
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the correlation ID of ctx, empty if it has none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ValidRequestID reports whether an incoming ID is safe to log and echo:
+// printable ASCII without spaces, up to 128 characters
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package hybridlog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retention deletes rotated files of every date, not just the backups of
+// the current file lumberjack knows about. With allDates it applies each
+// file's MaxAgeDays and MaxBackups to all of its rotated files, and it
+// deletes the oldest once the logger's files take more than maxTotal bytes,
+// then those its RetentionPolicy returns. It runs after each rotation and
+// every minute, the files in use count towards the total but are never
+// deleted
+type retention struct {
+	c        *core
+	maxTotal atomic.Int64 // 0 for no limit
+	allDates bool
+	policy   RetentionPolicy // nil for none
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newRetention(c *core, maxTotalMB int, allDates bool, policy RetentionPolicy) *retention {
+	r := &retention{
+		c:        c,
+		allDates: allDates,
+		policy:   policy,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	r.maxTotal.Store(int64(maxTotalMB) << 20)
+	go r.run()
+	return r
+}
+
+// trigger schedules a cleanup, called after a rotation
+func (r *retention) trigger() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *retention) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		r.enforce()
+		select {
+		case <-r.wake:
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *retention) enforce() {
+	type limits struct{ count, age int }
+	var total int64
+	var names []string // file names, longest first so app-error.log wins over app.log
+	owners := map[string]limits{}
+	for _, f := range r.c.files() {
+		f.mu.Lock()
+		name := f.lumber.Filename
+		names = append(names, f.fileName)
+		owners[f.fileName] = limits{f.lumber.MaxBackups, f.lumber.MaxAge}
+		f.mu.Unlock()
+		if info, err := os.Stat(name); err == nil {
+			total += info.Size()
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	inUse := total
+
+	type rotated struct {
+		path  string
+		owner string
+		size  int64
+		mod   time.Time
+	}
+	var files []rotated
+	for _, path := range r.c.rotatedFiles() {
+		if info, err := os.Stat(path); err == nil {
+			files = append(files, rotated{path, fileOwner(r.c.file.rotation, names, path), info.Size(), info.ModTime()})
+			total += info.Size()
+		}
+	}
+	// Newest first, the ones to keep come before those to delete
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	remove := func(f rotated) {
+		if err := os.Remove(f.path); err != nil {
+			if !os.IsNotExist(err) { // another process sharing the files got to it
+				r.c.reportError(err)
+			}
+			return
+		}
+		total -= f.size
+	}
+
+	now := time.Now()
+	count := map[string]int{}
+	var kept []rotated
+	for _, f := range files {
+		if r.allDates {
+			l := owners[f.owner]
+			if l.age > 0 && now.Sub(f.mod) > time.Duration(l.age)*24*time.Hour {
+				remove(f)
+				continue
+			}
+			if count[f.owner]++; l.count > 0 && count[f.owner] > l.count {
+				remove(f)
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+
+	maxTotal := r.maxTotal.Load()
+	for maxTotal > 0 && total > maxTotal && len(kept) > 0 {
+		remove(kept[len(kept)-1])
+		kept = kept[:len(kept)-1]
+	}
+
+	if r.policy == nil {
+		return
+	}
+	s := RetentionState{Now: now, InUse: inUse}
+	s.DiskFree, s.DiskTotal, _ = diskUsage(r.c.file.logDir)
+	byPath := map[string]rotated{}
+	for _, f := range kept {
+		s.Files = append(s.Files, RetainedFile{Path: f.path, File: f.owner, Size: f.size, ModTime: f.mod})
+		byPath[f.path] = f
+	}
+	for _, path := range r.policy.Expired(s) {
+		if f, ok := byPath[path]; ok { // never the files in use
+			remove(f)
+			delete(byPath, path)
+		}
+	}
+}
+
+// fileOwner returns which of the logger's file names a rotated file belongs to
+func fileOwner(r rotation, names []string, path string) string {
+	base := filepath.Base(path)
+	for _, name := range names {
+		if r.owns(name, base) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Close stops the background cleanup
+func (r *retention) Close() {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+// SetRetention changes at runtime how many rotated files of the main file
+// are kept and for how long, and the cap on the total size of all files,
+// 0 for no limit
+func (h *HybridLogger) SetRetention(maxBackups, maxAgeDays, maxTotalMB int) {
+	h.file.setLimits(maxBackups, maxAgeDays)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.retention == nil {
+		if maxTotalMB > 0 {
+			h.retention = newRetention(h.core, maxTotalMB, false, nil)
+		}
+		return
+	}
+	h.retention.maxTotal.Store(int64(maxTotalMB) << 20)
+	h.retention.trigger()
+}
+
+// retentionLimits returns the limits SetRetention takes, as they are now
+func (h *HybridLogger) retentionLimits() [3]int {
+	var l [3]int
+	h.file.mu.Lock()
+	l[0], l[1] = h.file.lumber.MaxBackups, h.file.lumber.MaxAge
+	h.file.mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.retention != nil {
+		l[2] = int(h.retention.maxTotal.Load() >> 20)
+	}
+	return l
+}
@@ -0,0 +1,143 @@
+package hybridlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ringBuffer keeps the last entries of every level for crash dumps
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []*logrus.Entry
+	next    int
+	full    bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{entries: make([]*logrus.Entry, size)}
+}
+
+// levelGate enforces the level of a logger with a ring buffer. Its logrus
+// logger is set to Trace so entries below the level reach the ring, the
+// gate drops them before the other hooks run
+type levelGate struct {
+	c     *core
+	level atomic.Uint32
+}
+
+func (g *levelGate) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (g *levelGate) Fire(e *logrus.Entry) error {
+	if e.Level <= logrus.Level(g.level.Load()) {
+		return nil
+	}
+	// Only kept for crash dumps, redacted like written entries
+	(&redactHook{c: g.c}).Fire(e)
+	g.c.ring.add(e)
+	dropEntry(e)
+	return nil
+}
+
+// add keeps a copy of e, formatted only when dumped
+func (r *ringBuffer) add(e *logrus.Entry) {
+	c := &logrus.Entry{
+		Logger:  e.Logger,
+		Data:    copyFields(e.Data),
+		Time:    e.Time,
+		Level:   e.Level,
+		Caller:  e.Caller,
+		Message: e.Message,
+	}
+	delete(c.Data, dropKey)
+
+	r.mu.Lock()
+	r.entries[r.next] = c
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+// recent returns the kept entries, oldest first
+func (r *ringBuffer) recent() []*logrus.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]*logrus.Entry(nil), r.entries[:r.next]...)
+	}
+	return append(append([]*logrus.Entry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// ringHook keeps the entries that are written, those below the level are
+// kept by levelGate
+type ringHook struct {
+	h *HybridLogger
+}
+
+func (r *ringHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (r *ringHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	r.h.ring.add(e)
+	if e.Level <= logrus.FatalLevel {
+		if _, err := r.h.DumpRecent(); err != nil {
+			r.h.reportError(err)
+		}
+	}
+	return nil
+}
+
+// DumpRecent writes the entries kept by the ring buffer to
+// crash-<timestamp>.log in the log dir and returns its path. It's done on
+// Panic and Fatal entries, and can be called e.g. when recovering a panic
+func (h *HybridLogger) DumpRecent() (string, error) {
+	if h.ring == nil {
+		return "", fmt.Errorf("no ring buffer, see WithRingBuffer")
+	}
+	h.outMu.RLock()
+	formatter := h.formatter
+	h.outMu.RUnlock()
+
+	var buf bytes.Buffer
+	for _, e := range h.ring.recent() {
+		line, err := formatter.Format(e)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(line)
+	}
+	path := filepath.Join(h.file.logDir, "crash-"+time.Now().UTC().Format(backupTimeFormat)+".log")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	return path, h.file.perm.apply(path, h.file.perm.fileMode)
+}
+
+// level returns the active level of h
+func (h *HybridLogger) level() logrus.Level {
+	if h.gate != nil {
+		return logrus.Level(h.gate.level.Load())
+	}
+	return h.Logger.GetLevel()
+}
+
+// setLevel sets the active level of h
+func (h *HybridLogger) setLevel(lvl logrus.Level) {
+	if h.gate == nil {
+		h.Logger.SetLevel(lvl)
+		return
+	}
+	h.gate.level.Store(uint32(lvl))
+	h.Logger.SetLevel(logrus.TraceLevel)
+}
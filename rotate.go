@@ -0,0 +1,789 @@
+package hybridlog
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// lumberjackBackup matches the timestamp lumberjack adds to size-rotated backups
+var lumberjackBackup = regexp.MustCompile(`-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}`)
+
+// Common rotation intervals. Weekly periods are ISO weeks starting on
+// Monday, Monthly ones are calendar months whatever their length
+const (
+	Hourly  = time.Hour
+	Daily   = 24 * time.Hour
+	Weekly  = 7 * Daily
+	Monthly = 31 * Daily
+)
+
+// isoWeekLayout stands for the ISO week date, e.g. 2024-W05, which time
+// layouts can't express
+const isoWeekLayout = "2006-W01"
+
+// rotationInterval normalizes the configured interval: anything that is not
+// a whole number of hours dividing a day, a week or a month falls back to
+// daily rotation
+func rotationInterval(d time.Duration) time.Duration {
+	if d == Weekly || d == Monthly {
+		return d
+	}
+	if d <= 0 || d >= Daily || d%time.Hour != 0 || Daily%d != 0 {
+		return Daily
+	}
+	return d
+}
+
+// periodFormat returns the filename date layout for the interval
+func periodFormat(interval time.Duration) string {
+	switch {
+	case interval == Monthly:
+		return "2006-01"
+	case interval == Weekly:
+		return isoWeekLayout
+	case interval < Daily:
+		return "2006-01-02-15"
+	}
+	return "2006-01-02"
+}
+
+// formatPeriod formats the start of a period with layout
+func formatPeriod(start time.Time, layout string) string {
+	if layout == isoWeekLayout {
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return start.Format(layout)
+}
+
+// validateDatePattern checks that a filename date layout is parseable and
+// fine-grained enough to tell the rotation periods apart
+func validateDatePattern(layout string, interval time.Duration) error {
+	sample := periodStart(time.Date(2024, time.November, 23, 13, 0, 0, 0, time.UTC), interval)
+	parsed, err := time.ParseInLocation(layout, sample.Format(layout), time.UTC)
+	if err != nil {
+		return fmt.Errorf("invalid date pattern %q: %v", layout, err)
+	}
+	if !parsed.Equal(sample) {
+		return fmt.Errorf("invalid date pattern %q: does not identify a %v rotation period", layout, interval)
+	}
+	return nil
+}
+
+// periodStart returns the start of the rotation period containing t
+func periodStart(t time.Time, interval time.Duration) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch {
+	case interval == Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case interval == Weekly:
+		return day.AddDate(0, 0, -(int(t.Weekday())+6)%7) // back to Monday
+	case interval >= Daily:
+		return day
+	}
+	slot := t.Hour() / int(interval/time.Hour) * int(interval/time.Hour)
+	return day.Add(time.Duration(slot) * time.Hour)
+}
+
+// dateToken marks where the date goes in a file name, without it the date
+// is added before the extension
+const dateToken = "{date}"
+
+// expandFileName replaces the {host} and {pid} placeholders of a file name
+// and {name}, the name of the program
+func expandFileName(fileName string) string {
+	if !strings.Contains(fileName, "{") {
+		return fileName
+	}
+	host, _ := os.Hostname()
+	program := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return strings.NewReplacer(
+		"{host}", host,
+		"{pid}", strconv.Itoa(os.Getpid()),
+		"{name}", program,
+	).Replace(fileName)
+}
+
+// datedParts splits a file name around its date, app.log into "app-" and ".log"
+func datedParts(fileName string) (before, after string) {
+	if i := strings.Index(fileName, dateToken); i >= 0 {
+		return fileName[:i], fileName[i+len(dateToken):]
+	}
+	ext := filepath.Ext(fileName)
+	return fileName[:len(fileName)-len(ext)] + "-", ext
+}
+
+// datedFileName turns app.log into app-<date>.log
+func datedFileName(fileName, date string) string {
+	before, after := datedParts(fileName)
+	return before + date + after
+}
+
+// undatedName returns the file name without its date placeholder and the
+// separator next to it, app-{date}.log becomes app.log
+func undatedName(fileName string) string {
+	i := strings.Index(fileName, dateToken)
+	if i < 0 {
+		return fileName
+	}
+	before, after := fileName[:i], fileName[i+len(dateToken):]
+	if n := len(before); n > 0 && strings.ContainsRune("-_.", rune(before[n-1])) {
+		before = before[:n-1]
+	} else if before == "" && after != "" && strings.ContainsRune("-_.", rune(after[0])) {
+		after = after[1:]
+	}
+	return before + after
+}
+
+// rotation decides which period, and so which dated file, a write belongs to
+type rotation struct {
+	interval   time.Duration  // rotation interval, 24h for daily
+	timeFormat string         // date layout in file names, or of the directory with inDir
+	inDir      bool           // files are written as <date>/<name> instead of <name>-<date>
+	loc        *time.Location // timezone the rotation boundary is computed in
+	at         time.Duration  // time of day periods start at, 0 for midnight
+}
+
+// period returns the file name date for t
+func (r rotation) period(t time.Time) string {
+	if r.at == 0 {
+		return formatPeriod(periodStart(t.In(r.loc), r.interval), r.timeFormat)
+	}
+	return formatPeriod(r.start(t), r.timeFormat)
+}
+
+// start returns the wall clock time the period containing t started at, as
+// a UTC time so that DST changes don't move it. When DST skips r.at, the
+// period starts as the clock jumps past it
+func (r rotation) start(t time.Time) time.Time {
+	t = t.In(r.loc)
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return periodStart(wall.Add(-r.at), r.interval).Add(r.at)
+}
+
+// end returns when the period containing t ends, or an hour later at the
+// latest. Periods change on whole minutes, which are checked one by one as
+// DST changes make the wall clock skip or repeat some of them
+func (r rotation) end(t time.Time) time.Time {
+	period := r.period(t)
+	next := t.Truncate(time.Minute)
+	for i := 0; i < 60; i++ {
+		if next = next.Add(time.Minute); r.period(next) != period {
+			break
+		}
+	}
+	return next
+}
+
+// parseRotateAt parses the "HH:MM" of Options.RotateAt
+func parseRotateAt(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid RotateAt %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// path returns the path of the file called name for period, relative to the log dir
+func (r rotation) path(name, period string) string {
+	if r.inDir {
+		return filepath.Join(filepath.FromSlash(period), strings.ReplaceAll(name, dateToken, period))
+	}
+	return datedFileName(name, period)
+}
+
+// owns reports whether base, a path relative to the log dir or the name of
+// a file in a date directory, is one written for the file called name: a
+// dated file, a backup of one, compressed or not, or a .tar.gz bundle. The
+// date must be one of r's periods, app-worker-2024-01-01.log belongs to
+// another logger than app.log
+func (r rotation) owns(name, base string) bool {
+	base = strings.TrimSuffix(base, ".gz")
+	if b, ok := strings.CutSuffix(base, ".tar"); ok {
+		base = b + filepath.Ext(name)
+	}
+	base = trimBackupTime(base)
+	if r.inDir && !strings.Contains(name, dateToken) {
+		return base == name
+	}
+	before, after := datedParts(name)
+	date, ok := strings.CutPrefix(base, before)
+	if !ok {
+		return false
+	}
+	date, ok = strings.CutSuffix(date, after)
+	return ok && r.isPeriod(date)
+}
+
+var isoWeek = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+
+// isPeriod reports whether s is a date formatted as r formats periods
+func (r rotation) isPeriod(s string) bool {
+	if r.timeFormat == isoWeekLayout {
+		return isoWeek.MatchString(s)
+	}
+	_, err := time.Parse(r.timeFormat, s)
+	return err == nil
+}
+
+// trimBackupTime removes the timestamp lumberjack adds before the extension
+// of its backups
+func trimBackupTime(base string) string {
+	ext := filepath.Ext(base)
+	stem := base[:len(base)-len(ext)]
+	if loc := lumberjackBackup.FindStringIndex(stem); loc != nil && loc[1] == len(stem) {
+		return stem[:loc[0]] + ext
+	}
+	return base
+}
+
+// rotatingFile is a lumberjack file that moves to a new dated file name
+// whenever the rotation period changes
+type rotatingFile struct {
+	mu          sync.Mutex
+	lumber      *lumberjack.Logger
+	logDir      string
+	fileName    string
+	currentDate string
+	periodStart time.Time    // wall clock start of currentDate, see rotation.start
+	periodEnd   atomic.Int64 // unix nanos, writes before it skip the date check
+	rotation    rotation
+	closed      bool
+	rotated     func(oldPath, newPath string) // called after each rotation, may be nil
+	report      func(error)                   // reports background failures, may be nil
+	link        string                        // "latest" link kept pointing at the file, empty if disabled
+	perm        filePerm
+	encrypt     *encrypter  // nil unless the file is encrypted
+	chain       *hashChain  // nil unless lines are signed
+	shared      *sharedFile // nil unless other processes write the file too
+	compressing sync.WaitGroup
+	writes      atomic.Uint64 // successful writes
+	written     atomic.Uint64 // bytes written
+	writeErr    error         // result of the last write, see Healthy
+
+	// fsync state, pending counts the writes since the last fsync
+	syncEvery    int  // fsync every syncEvery writes, 0 for never
+	syncInterval bool // a syncer fsyncs the file periodically
+	pending      int
+	syncFile     *os.File
+	syncName     string
+
+	// lumberjack rotates by size on its own, the size is tracked the way it
+	// does to tell when that happens
+	opened bool
+	size   int64
+
+	policy     RotationPolicy // nil unless rotation follows a custom policy
+	started    time.Time      // when the current file was started, see RotationState
+	lines      int64
+	countLines bool // count the lines of a file found on startup, for MaxLines
+}
+
+func newRotatingFile(logDir string, opts FileOptions, r rotation) *rotatingFile {
+	now := time.Now()
+	opts.FileName = expandFileName(opts.FileName)
+	f := &rotatingFile{
+		logDir:      logDir,
+		fileName:    opts.FileName,
+		currentDate: r.period(now),
+		periodStart: r.start(now),
+		rotation:    r,
+	}
+	f.periodEnd.Store(r.end(now).UnixNano())
+	f.lumber = &lumberjack.Logger{
+		Filename:   filepath.Join(logDir, r.path(opts.FileName, f.currentDate)),
+		MaxSize:    opts.MaxSizeMB,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAgeDays,
+		Compress:   opts.Compress,
+	}
+	return f
+}
+
+// Write writes to the file of the current period
+func (f *rotatingFile) Write(p []byte) (n int, err error) {
+	// The date is only worked out again once the period may have ended
+	if now := time.Now(); now.UnixNano() >= f.periodEnd.Load() {
+		f.newPeriod(now)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer func() { f.writeErr = err }()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if !f.opened {
+		if err := f.perm.create(f.lumber.Filename); err != nil {
+			return 0, err
+		}
+		if f.chain != nil {
+			if err := f.chain.resume(f.lumber.Filename, f.encrypt); err != nil {
+				return 0, err
+			}
+		}
+		f.size, f.lines, f.started = 0, 0, time.Now()
+		if info, err := os.Stat(f.lumber.Filename); err == nil && info.Size() > 0 {
+			f.size, f.started = info.Size(), info.ModTime()
+			if f.countLines && f.encrypt == nil {
+				if f.lines, err = countLines(f.lumber.Filename); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	lines := int64(bytes.Count(p, []byte{'\n'}))
+	f.checkPolicy(len(p), lines)
+	f.lines += lines
+	if f.shared != nil {
+		return f.writeShared(p)
+	}
+	data, mac, err := f.encode(p)
+	if err != nil {
+		return 0, err
+	}
+	bySize := f.willRotate(len(data))
+	if bySize {
+		f.beforeClose()
+	}
+	if bySize && f.chain != nil {
+		f.chain.reset() // the lines go to a new file
+		if data, mac, err = f.encode(p); err != nil {
+			return 0, err
+		}
+	}
+	if _, err = f.lumber.Write(data); err != nil {
+		return 0, err
+	}
+	if f.chain != nil {
+		f.chain.prev = mac
+	}
+	f.writes.Add(1)
+	f.written.Add(uint64(len(data)))
+	f.wrote()
+	if bySize {
+		f.size, f.lines, f.started = 0, lines, time.Now()
+		if err := f.perm.apply(f.lumber.Filename, f.perm.fileMode); err != nil && f.report != nil {
+			f.report(err)
+		}
+		f.sizeRotated()
+	}
+	if !f.opened || bySize {
+		f.updateLink()
+	}
+	f.opened = true
+	f.size += int64(len(data))
+	return len(p), nil
+}
+
+// newPeriod moves to the file of the period now falls in
+func (f *rotatingFile) newPeriod(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed || now.UnixNano() < f.periodEnd.Load() {
+		return // another write got here first
+	}
+	f.periodEnd.Store(f.rotation.end(now).UnixNano())
+
+	// Check if the rotation period has changed, never going back to the
+	// previous one when the clock is turned back for DST
+	currentDate := f.rotation.period(now)
+	if f.currentDate == currentDate {
+		return
+	}
+	start := f.rotation.start(now)
+	if start.Before(f.periodStart) {
+		return
+	}
+	f.periodStart = start
+	f.beforeClose()
+
+	// Close the current log file
+	if f.lumber != nil {
+		f.lumber.Close()
+	}
+
+	// Create a new log file with updated date
+	oldPath := f.lumber.Filename
+	f.lumber = f.newLumber(currentDate)
+	f.currentDate = currentDate
+	f.opened = false
+	if f.rotated != nil {
+		f.rotated(oldPath, f.lumber.Filename)
+	}
+}
+
+// encode signs and encrypts p as configured, returning what is written to
+// the file and the MAC of the last line signed
+func (f *rotatingFile) encode(p []byte) ([]byte, []byte, error) {
+	var mac []byte
+	if f.chain != nil {
+		p, mac = f.chain.sign(p)
+	}
+	if f.encrypt != nil {
+		sealed, err := f.encrypt.seal(p)
+		return sealed, mac, err
+	}
+	return p, mac, nil
+}
+
+// writeShared writes p through the shared file, which rotates by size
+// itself instead of lumberjack
+func (f *rotatingFile) writeShared(p []byte) (int, error) {
+	data, _, err := f.encode(p)
+	if err != nil {
+		return 0, err
+	}
+	backup, err := f.shared.write(f.lumber.Filename, data, f.maxSize())
+	if err != nil {
+		return 0, err
+	}
+	f.writes.Add(1)
+	f.written.Add(uint64(len(data)))
+	f.wrote()
+	if backup != "" {
+		f.sharedRotated(backup)
+	}
+	if !f.opened {
+		f.updateLink()
+	}
+	f.opened = true
+	return len(p), nil
+}
+
+// sharedRotated compresses a backup made in shared mode as lumberjack would
+// and reports the rotation
+func (f *rotatingFile) sharedRotated(backup string) {
+	if err := f.perm.apply(f.lumber.Filename, f.perm.fileMode); err != nil && f.report != nil {
+		f.report(err)
+	}
+	if f.lumber.Compress {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			if _, err := gzipFile(backup, f.perm); err != nil && f.report != nil {
+				f.report(err)
+			}
+		}()
+	}
+	if f.rotated != nil {
+		f.rotated(backup, f.lumber.Filename)
+	}
+}
+
+// maxSize returns the size lumberjack rotates the file at
+func (f *rotatingFile) maxSize() int64 {
+	if f.lumber.MaxSize == 0 {
+		return 100 << 20 // lumberjack's default
+	}
+	return int64(f.lumber.MaxSize) << 20
+}
+
+// willRotate reports whether lumberjack will rotate before writing n bytes
+func (f *rotatingFile) willRotate(n int) bool {
+	max := f.maxSize()
+	if f.opened {
+		return f.size+int64(n) > max
+	}
+	info, err := os.Stat(f.lumber.Filename)
+	if err != nil {
+		f.size = 0
+		return false
+	}
+	f.size = info.Size()
+	return info.Size()+int64(n) >= max
+}
+
+// sizeRotated reports a rotation done by lumberjack, which moved the file to
+// the newest timestamped backup
+func (f *rotatingFile) sizeRotated() {
+	if f.rotated == nil {
+		return
+	}
+	name := f.lumber.Filename
+	base := filepath.Base(name)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "-"
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	var newest string
+	for _, e := range entries {
+		b := e.Name()
+		if strings.HasPrefix(b, prefix) && strings.HasSuffix(b, ext) && lumberjackBackup.MatchString(b) && b > newest {
+			newest = b // the timestamps sort as strings
+		}
+	}
+	if newest != "" {
+		newest = filepath.Join(filepath.Dir(name), newest)
+	}
+	f.rotated(newest, name)
+}
+
+// newLumber creates a lumberjack logger for the given period, keeping the
+// size and retention settings of the current one
+func (f *rotatingFile) newLumber(date string) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   filepath.Join(f.logDir, f.rotation.path(f.fileName, date)),
+		MaxSize:    f.lumber.MaxSize,
+		MaxBackups: f.lumber.MaxBackups,
+		MaxAge:     f.lumber.MaxAge,
+		Compress:   f.lumber.Compress,
+	}
+}
+
+// setLimits changes how many backups are kept and for how long. It swaps in
+// a new lumberjack.Logger, the cleanup goroutine of the old one reads them
+// without locking, and the new one reopens the file on the next write
+func (f *rotatingFile) setLimits(maxBackups, maxAgeDays int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed || f.lumber.MaxBackups == maxBackups && f.lumber.MaxAge == maxAgeDays {
+		return
+	}
+	l := f.newLumber(f.currentDate)
+	l.Filename = f.lumber.Filename
+	l.MaxBackups, l.MaxAge = maxBackups, maxAgeDays
+	f.lumber.Close()
+	f.lumber = l
+}
+
+// Rotate starts a fresh file, keeping the current one as a backup
+func (f *rotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.rotateLocked()
+}
+
+func (f *rotatingFile) rotateLocked() error {
+	f.beforeClose()
+	f.lines, f.started = 0, time.Now()
+	if f.shared != nil {
+		backup, err := f.shared.rotate(f.lumber.Filename)
+		if err != nil {
+			return err
+		}
+		f.opened = true
+		f.sharedRotated(backup)
+		f.updateLink()
+		return nil
+	}
+	// The file is moved aside here rather than by lumberjack, whose backup
+	// names would be the same for rotations within a millisecond
+	if err := f.lumber.Close(); err != nil {
+		return err
+	}
+	name := f.lumber.Filename
+	backup := uniqueBackupName(name, time.Now())
+	if err := os.Rename(name, backup); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		backup = ""
+	}
+	// Lumberjack creates the new file and compresses and cleans up backups
+	if err := f.lumber.Rotate(); err != nil {
+		return err
+	}
+	if err := f.perm.apply(name, f.perm.fileMode); err != nil && f.report != nil {
+		f.report(err)
+	}
+	f.opened, f.size = true, 0
+	if f.chain != nil {
+		f.chain.reset()
+	}
+	if backup != "" && f.rotated != nil {
+		f.rotated(backup, name)
+	}
+	f.updateLink()
+	return nil
+}
+
+// Reopen closes the file, the next write opens it again by name
+func (f *rotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.opened = false
+	f.beforeClose()
+	if f.shared != nil {
+		return f.shared.closeFile()
+	}
+	return f.lumber.Close()
+}
+
+// Sync waits for in-flight writes to complete and fsyncs the file if
+// anything was written since the last time
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	return f.syncLocked()
+}
+
+// Close closes the file, later writes fail with os.ErrClosed
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.beforeClose()
+	if f.shared != nil {
+		f.compressing.Wait()
+		return f.shared.Close()
+	}
+	return f.lumber.Close()
+}
+
+// files returns every rotating file written by the logger
+func (c *core) files() []*rotatingFile {
+	files := []*rotatingFile{c.file}
+	if c.errorFile != nil {
+		files = append(files, c.errorFile)
+	}
+	if c.accessFile != nil {
+		files = append(files, c.accessFile)
+	}
+	if c.auditFile != nil {
+		files = append(files, c.auditFile)
+	}
+	return files
+}
+
+// rotatedFiles returns the files of every rotating file that are no longer
+// written to: those of past rotation periods, lumberjack's size-rotated
+// backups once compressed, if compression is on, and tar.gz bundles
+func (c *core) rotatedFiles() []string {
+	files := c.files()
+	seen := map[string]bool{} // the files in use, links to them and those already listed
+	for _, f := range files {
+		f.mu.Lock()
+		seen[f.lumber.Filename] = true
+		seen[filepath.Join(f.logDir, undatedName(f.fileName))] = true
+		f.mu.Unlock()
+	}
+
+	var paths []string
+	for _, f := range files {
+		f.mu.Lock()
+		dir, name, compress, r := f.logDir, f.fileName, f.lumber.Compress, f.rotation
+		f.mu.Unlock()
+
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if d != nil && d.IsDir() && path != dir && !r.inDir {
+				return fs.SkipDir // only date directories hold rotated files
+			}
+			if err != nil || d.IsDir() || seen[path] {
+				return nil
+			}
+			// Dated names start with the file name, in date directories
+			// only the base name is the file name
+			match, _ := filepath.Rel(dir, path)
+			match = filepath.ToSlash(match)
+			if r.inDir {
+				if !r.isPeriod(filepath.ToSlash(filepath.Dir(match))) {
+					return nil
+				}
+				match = filepath.Base(path)
+			}
+			if !r.owns(name, match) {
+				return nil
+			}
+			plain := strings.TrimSuffix(match, ".gz")
+			if lumberjackBackup.MatchString(plain) {
+				if compress && plain == match {
+					return nil // lumberjack is about to compress it
+				}
+				if plain != match {
+					if _, err := os.Stat(strings.TrimSuffix(path, ".gz")); err == nil {
+						return nil // still being compressed
+					}
+				}
+			}
+			seen[path] = true
+			paths = append(paths, path)
+			return nil
+		})
+	}
+	return paths
+}
+
+// notifyRotated tells the background workers that a file was rotated
+func (c *core) notifyRotated() {
+	if c.archiver != nil {
+		c.archiver.trigger()
+	}
+	if c.retention != nil {
+		c.retention.trigger()
+	}
+	if c.bundler != nil {
+		c.bundler.trigger()
+	}
+}
+
+// fileRotated is called by a rotating file, with its lock held, after it
+// moved oldPath aside and now writes newPath
+func (c *core) fileRotated(oldPath, newPath string) {
+	c.metrics.rotations.Add(1)
+	c.notifyRotated()
+
+	c.rotateMu.RLock()
+	callbacks := c.onRotate
+	c.rotateMu.RUnlock()
+
+	if len(callbacks) > 0 {
+		go func() {
+			for _, fn := range callbacks {
+				fn(oldPath, newPath)
+			}
+		}()
+	}
+}
+
+// OnRotate registers fn to be called after a log file is rotated, by date or
+// by size, with the path of the completed file and the one now written. It
+// runs on its own goroutine, with compression on the completed file is
+// replaced by oldPath+".gz" shortly after
+func (h *HybridLogger) OnRotate(fn func(oldPath, newPath string)) {
+	h.rotateMu.Lock()
+	defer h.rotateMu.Unlock()
+
+	h.onRotate = append(h.onRotate[:len(h.onRotate):len(h.onRotate)], fn)
+}
+
+// Rotate closes the current log files and starts fresh ones, the closed
+// files are kept as timestamped backups subject to MaxBackups/MaxAgeDays
+func (h *HybridLogger) Rotate() error {
+	if h.async != nil {
+		h.async.Flush() // queued entries belong to the old file
+	}
+	if err := h.flushBuffers(); err != nil {
+		h.reportError(err)
+	}
+	for _, f := range h.files() {
+		if err := f.Rotate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package hybridlog
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// readEntries returns how many times each message appears in the log files
+// under dir
+func readEntries(t *testing.T, dir string) map[string]int {
+	t.Helper()
+	seen := map[string]int{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".log" {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		lines := bufio.NewScanner(file)
+		for lines.Scan() {
+			seen[lines.Text()]++
+		}
+		return lines.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return seen
+}
+
+func TestRotationKeepsEveryLine(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"max lines", []Option{WithMaxLines(10)}},
+		{"shared max lines", []Option{WithMaxLines(10), WithSharedFile()}},
+		{"size policy", []Option{WithRotationPolicy(SizePolicy(200))}},
+		{"custom policy", []Option{WithRotationPolicy(RotationPolicyFunc(func(s RotationState) bool {
+			return s.Lines >= 3
+		}))}},
+		{"shared custom policy", []Option{WithSharedFile(), WithRotationPolicy(RotationPolicyFunc(func(s RotationState) bool {
+			return s.Lines >= 3
+		}))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]Option{
+				WithLogDir(dir),
+				WithMaxBackups(0),
+				WithMaxAge(0),
+				WithFormatter(&LogfmtFormatter{}),
+			}, tt.opts...)
+			h, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			const n = 1000
+			for i := 0; i < n; i++ {
+				h.Info("entry " + strconv.Itoa(i))
+			}
+			if err := h.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			seen := readEntries(t, dir)
+			if len(seen) != n {
+				t.Fatalf("found %d of %d entries", len(seen), n)
+			}
+		})
+	}
+}
+
+func BenchmarkRotatingFileWrite64(b *testing.B) {
+	interval := rotationInterval(0)
+	r := rotation{interval: interval, timeFormat: periodFormat(interval), loc: time.Local}
+	f := newRotatingFile(b.TempDir(), FileOptions{FileName: "bench.log", MaxSizeMB: 10}, r)
+	defer f.Close()
+	line := []byte(`time="2024-05-06T07:08:09Z" level=info msg="request handled" user=alice id=12345` + "\n")
+
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	procs := runtime.GOMAXPROCS(0)
+	b.SetParallelism((64 + procs - 1) / procs) // at least 64 goroutines
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := f.Write(line); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestRetentionKeepsOtherLoggersFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().AddDate(0, 0, -10)
+	files := []string{
+		"app-2024-01-01.log",
+		"app-worker-2024-01-01.log",
+		filepath.Join("sub", "app-2024-01-01.log"),
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app, err := New(WithLogDir(dir), WithFileName("app.log"), WithRetainAcrossDates(), WithMaxAge(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := New(WithLogDir(dir), WithFileName("app-worker.log"), WithRetainAcrossDates(), WithMaxAge(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, files[0])); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the expired file of app.log was kept")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	app.Close()
+	worker.Close()
+
+	for _, name := range files[1:] {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was deleted: %v", name, err)
+		}
+	}
+}
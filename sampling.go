@@ -0,0 +1,113 @@
+package hybridlog
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingRule keeps part of the entries of a level, see WithSampling
+type SamplingRule struct {
+	Every       int     // keep 1 in Every entries (the first, the Every+1th, ...), 0 or 1 keeps all
+	Probability float64 // keep each entry with this probability, used when Every is not set
+}
+
+// samplingHook drops entries according to per-level rules and counts them
+type samplingHook struct {
+	rules   map[logrus.Level]SamplingRule
+	seen    [logrus.TraceLevel + 1]atomic.Uint64
+	dropped [logrus.TraceLevel + 1]atomic.Uint64
+}
+
+func newSamplingHook(rules map[logrus.Level]SamplingRule) *samplingHook {
+	copied := make(map[logrus.Level]SamplingRule, len(rules))
+	for lvl, r := range rules {
+		copied[lvl] = r
+	}
+	return &samplingHook{rules: copied}
+}
+
+func (s *samplingHook) Levels() []logrus.Level {
+	levels := make([]logrus.Level, 0, len(s.rules))
+	for lvl := range s.rules {
+		if lvl <= logrus.TraceLevel {
+			levels = append(levels, lvl)
+		}
+	}
+	return levels
+}
+
+func (s *samplingHook) Fire(e *logrus.Entry) error {
+	r := s.rules[e.Level]
+	n := s.seen[e.Level].Add(1)
+
+	keep := true
+	if r.Every > 1 {
+		keep = (n-1)%uint64(r.Every) == 0
+	} else if r.Probability > 0 && r.Probability < 1 {
+		keep = rand.Float64() < r.Probability
+	}
+	if !keep {
+		s.dropped[e.Level].Add(1)
+		dropEntry(e)
+	}
+	return nil
+}
+
+// SampledOut returns how many entries of each level sampling has dropped
+func (h *HybridLogger) SampledOut() map[logrus.Level]uint64 {
+	h.hookMu.RLock()
+	sampler := h.sampler
+	h.hookMu.RUnlock()
+
+	counts := map[logrus.Level]uint64{}
+	if sampler == nil {
+		return counts
+	}
+	for lvl := range sampler.rules {
+		if lvl <= logrus.TraceLevel {
+			counts[lvl] = sampler.dropped[lvl].Load()
+		}
+	}
+	return counts
+}
+
+// SetSampling replaces the sampling rules at runtime, nil or empty rules
+// turn sampling off. The counts of SampledOut carry over
+func (h *HybridLogger) SetSampling(rules map[logrus.Level]SamplingRule) {
+	h.hookMu.Lock()
+	defer h.hookMu.Unlock()
+
+	old := h.sampler
+	var sampler *samplingHook
+	if len(rules) > 0 {
+		sampler = newSamplingHook(rules)
+		if old != nil {
+			for lvl := range sampler.seen {
+				sampler.seen[lvl].Store(old.seen[lvl].Load())
+				sampler.dropped[lvl].Store(old.dropped[lvl].Load())
+			}
+		}
+	}
+
+	// Sampling runs first so the other hooks skip what it drops
+	hooks := make(logrus.LevelHooks, len(h.hooks))
+	for _, lvl := range logrus.AllLevels {
+		var hs []logrus.Hook
+		if _, ok := rules[lvl]; ok && sampler != nil {
+			hs = append(hs, sampler)
+		}
+		for _, hook := range h.hooks[lvl] {
+			if old != nil && hook == logrus.Hook(old) {
+				continue
+			}
+			hs = append(hs, hook)
+		}
+		if len(hs) > 0 {
+			hooks[lvl] = hs
+		}
+	}
+	h.hooks = hooks
+	h.sampler = sampler
+}
@@ -0,0 +1,148 @@
+package hybridlog
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupTimeFormat is the timestamp lumberjack puts in backup names, kept
+// for backups made in shared mode so the rest of the package finds them
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// sharedFile writes a log file several processes append to. Each write
+// holds an advisory lock on a lock file next to it, under which the file
+// is reopened if another process rotated it and rotated by size here
+type sharedFile struct {
+	lockPath string
+	lock     *os.File
+	file     *os.File
+	name     string // path file was opened with
+	mode     os.FileMode
+	sync     bool // fsync the file before closing it
+}
+
+func newSharedFile(logDir, fileName string, mode os.FileMode) *sharedFile {
+	if mode == 0 {
+		mode = 0600
+	}
+	return &sharedFile{lockPath: filepath.Join(logDir, "."+fileName+".lock"), mode: mode}
+}
+
+// write appends p to name, first moving the file to a timestamped backup if
+// p would take it over max bytes. It returns the backup's path if so
+func (s *sharedFile) write(name string, p []byte, max int64) (backup string, err error) {
+	if err := s.acquire(); err != nil {
+		return "", err
+	}
+	defer unlockFile(s.lock)
+
+	if err := s.open(name); err != nil {
+		return "", err
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > 0 && info.Size()+int64(len(p)) > max {
+		backup = uniqueBackupName(name, time.Now())
+		if err := os.Rename(name, backup); err != nil {
+			return "", err
+		}
+		if err := s.open(name); err != nil {
+			return "", err
+		}
+	}
+	_, err = s.file.Write(p)
+	return backup, err
+}
+
+// acquire takes the lock, blocking while another process holds it
+func (s *sharedFile) acquire() error {
+	if s.lock == nil {
+		lock, err := os.OpenFile(s.lockPath, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return err
+		}
+		s.lock = lock
+	}
+	return lockFile(s.lock)
+}
+
+// open opens name for appending unless the file open is still the one
+// found under that name
+func (s *sharedFile) open(name string) error {
+	if s.file != nil && s.name == name {
+		cur, err1 := s.file.Stat()
+		disk, err2 := os.Stat(name)
+		if err1 == nil && err2 == nil && os.SameFile(cur, disk) {
+			return nil
+		}
+	}
+	s.closeFile()
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, s.mode)
+	if err != nil {
+		return err
+	}
+	s.file, s.name = file, name
+	return nil
+}
+
+// rotate moves name to a backup, which the other processes notice on
+// their next write
+func (s *sharedFile) rotate(name string) (string, error) {
+	if err := s.acquire(); err != nil {
+		return "", err
+	}
+	defer unlockFile(s.lock)
+
+	backup := uniqueBackupName(name, time.Now())
+	if err := os.Rename(name, backup); err != nil {
+		return "", err
+	}
+	return backup, s.open(name)
+}
+
+func (s *sharedFile) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	if s.sync {
+		s.file.Sync()
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Close closes the log and lock files
+func (s *sharedFile) Close() error {
+	err := s.closeFile()
+	if s.lock != nil {
+		s.lock.Close()
+		s.lock = nil
+	}
+	return err
+}
+
+// uniqueBackupName returns the backup name for name at t, a millisecond
+// later at a time while a backup of that name exists, compressed or not, so
+// rotations within the same millisecond don't overwrite each other
+func uniqueBackupName(name string, t time.Time) string {
+	for {
+		backup := sharedBackupName(name, t)
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			if _, err := os.Lstat(backup + ".gz"); os.IsNotExist(err) {
+				return backup
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// sharedBackupName names a backup like lumberjack: app-2024-05-01.log
+// becomes app-2024-05-01-2024-05-01T10-00-00.000.log, in UTC
+func sharedBackupName(name string, t time.Time) string {
+	ext := filepath.Ext(name)
+	return name[:len(name)-len(ext)] + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
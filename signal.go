@@ -0,0 +1,58 @@
+package hybridlog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reopen closes the current log files, the next write opens them again by
+// name. Use it after an external tool (e.g. logrotate) has moved them away
+func (h *HybridLogger) Reopen() error {
+	for _, f := range h.files() {
+		if err := f.Reopen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnableSignalRotation reopens the log file whenever one of the given signals
+// arrives, SIGHUP if none are given. Calling it again replaces the signal set
+func (h *HybridLogger) EnableSignalRotation(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	h.mu.Lock()
+	old := h.sigCh
+	h.sigCh = ch
+	h.mu.Unlock()
+	stopSignals(old)
+
+	go func() {
+		for range ch {
+			if err := h.Reopen(); err != nil {
+				h.Logger.Errorf("failed to reopen log file: %v", err)
+			}
+		}
+	}()
+}
+
+// DisableSignalRotation stops reacting to the signals set by EnableSignalRotation
+func (h *HybridLogger) DisableSignalRotation() {
+	h.mu.Lock()
+	old := h.sigCh
+	h.sigCh = nil
+	h.mu.Unlock()
+	stopSignals(old)
+}
+
+func stopSignals(ch chan os.Signal) {
+	if ch != nil {
+		signal.Stop(ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,80 @@
+package hybridlog
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is a destination for formatted entries that third parties can
+// implement, see RegisterSink and AddSink. Write gets one entry rendered by
+// the file formatter, newline included, and must not keep entry after
+// returning. Errors are reported through OnError
+type Sink interface {
+	Write(entry []byte, level logrus.Level) error
+	Close() error
+}
+
+// SinkFactory creates a sink from its configuration, see Options.Sinks
+type SinkFactory func(config map[string]interface{}) (Sink, error)
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = map[string]SinkFactory{}
+)
+
+// RegisterSink makes a sink available by name to Options.Sinks and config
+// files, typically from the init function of the package providing it. It
+// panics if name is already registered, like database/sql.Register
+func RegisterSink(name string, factory SinkFactory) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	if _, ok := sinks[name]; ok {
+		panic(fmt.Sprintf("hybridlog: sink %q registered twice", name))
+	}
+	sinks[name] = factory
+}
+
+// newSink creates a registered sink
+func newSink(name string, config map[string]interface{}) (Sink, error) {
+	sinksMu.RLock()
+	factory, ok := sinks[name]
+	sinksMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q, register it with RegisterSink", name)
+	}
+	s, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sink %q: %v", name, err)
+	}
+	return s, nil
+}
+
+// sinkWriter adapts a Sink to the outputs
+type sinkWriter struct {
+	s Sink
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (w *sinkWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	if err := w.s.Write(p, level); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *sinkWriter) Close() error {
+	return w.s.Close()
+}
+
+// AddSink writes every entry to s as well, under name for SetSinkLevel.
+// Close closes it
+func (h *HybridLogger) AddSink(name string, s Sink) {
+	h.addOutput(&output{name: name, w: &sinkWriter{s}, level: logrus.TraceLevel, owned: true})
+}
@@ -0,0 +1,104 @@
+package hybridlog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slogHandler is a slog.Handler that writes records through HybridLogger
+type slogHandler struct {
+	h      *HybridLogger
+	fields logrus.Fields
+	prefix string // open groups as "group1.group2."
+}
+
+// SlogHandler returns a slog.Handler that writes records to the same files as h
+func (h *HybridLogger) SlogHandler() slog.Handler {
+	return &slogHandler{h: h, fields: logrus.Fields{}}
+}
+
+// Slog returns a *slog.Logger backed by h
+func (h *HybridLogger) Slog() *slog.Logger {
+	return slog.New(h.SlogHandler())
+}
+
+// slogLevel maps a slog level to logrus, never to Fatal or Panic so that a
+// slog call can't exit or panic the process
+func slogLevel(l slog.Level) logrus.Level {
+	switch {
+	case l >= slog.LevelError:
+		return logrus.ErrorLevel
+	case l >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case l >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case l >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
+
+func (s *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return s.h.level() >= slogLevel(l)
+}
+
+func (s *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(logrus.Fields, len(s.fields)+r.NumAttrs())
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, s.prefix, a)
+		return true
+	})
+
+	entry := s.h.Logger.WithContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(slogLevel(r.Level), r.Message)
+	return nil
+}
+
+func (s *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logrus.Fields, len(s.fields)+len(attrs))
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, s.prefix, a)
+	}
+	return &slogHandler{h: s.h, fields: fields, prefix: s.prefix}
+}
+
+func (s *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	return &slogHandler{h: s.h, fields: s.fields, prefix: s.prefix + name + "."}
+}
+
+// addAttr flattens a slog attribute into fields using dotted group keys
+func addAttr(fields logrus.Fields, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		group := v.Group()
+		if len(group) == 0 {
+			return
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[prefix+a.Key] = v.Any()
+}
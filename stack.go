@@ -0,0 +1,72 @@
+package hybridlog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StackTraceOptions configures the stack traces added to severe entries
+type StackTraceOptions struct {
+	Level *logrus.Level // least severe level given a trace, nil for Error
+	Depth int           // max frames, defaults to 32
+	Skip  int           // frames to skip below the logging call, for helpers that log errors
+}
+
+// stackHook adds a "stack" field with the frames leading to the logging
+// call, leaving out those of logrus and this package and the runtime's
+// frames starting the goroutine
+type stackHook struct {
+	opts   StackTraceOptions
+	levels []logrus.Level
+}
+
+// newStackHook expects a level validated by validateOptions
+func newStackHook(opts StackTraceOptions) *stackHook {
+	level := logrus.ErrorLevel
+	if opts.Level != nil {
+		level = *opts.Level
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = 32
+	}
+	return &stackHook{opts: opts, levels: logrus.AllLevels[:level+1]}
+}
+
+func (s *stackHook) Levels() []logrus.Level {
+	return s.levels
+}
+
+func (s *stackHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	if _, ok := e.Data["stack"]; ok {
+		return nil // e.g. from RecoverAndLog
+	}
+	pcs := make([]uintptr, s.opts.Depth+s.opts.Skip+32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	var b strings.Builder
+	skip, n := s.opts.Skip, 0
+	inLogger := true // the frames above the logging call
+	for f, more := frames.Next(); more && n < s.opts.Depth; f, more = frames.Next() {
+		if inLogger && callerSkipPackages[funcPackage(f.Function)] {
+			continue
+		}
+		inLogger = false
+		if funcPackage(f.Function) == "runtime" {
+			break // runtime.main or goexit below the goroutine's function
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		n++
+	}
+	e.Data["stack"] = b.String()
+	return nil
+}
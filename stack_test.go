@@ -0,0 +1,33 @@
+package hybridlog
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func withStackLevel(level logrus.Level) Option {
+	return func(o *Options) { o.StackTraces = &StackTraceOptions{Level: &level} }
+}
+
+func TestStackTraceLevels(t *testing.T) {
+	panicLevel, warnLevel := logrus.PanicLevel, logrus.WarnLevel
+	tests := []struct {
+		name  string
+		level *logrus.Level
+		want  []logrus.Level
+	}{
+		{"default", nil, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}},
+		{"panic", &panicLevel, []logrus.Level{logrus.PanicLevel}},
+		{"warn", &warnLevel, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newStackHook(StackTraceOptions{Level: tt.level}).Levels()
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package hybridlog
+
+import (
+	"io"
+	"log"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stdLogWriter logs each Write as one message, trailing newlines removed
+type stdLogWriter struct {
+	h     *HybridLogger
+	level logrus.Level
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\r\n")
+	if msg != "" {
+		w.h.Logger.Log(w.level, msg)
+	}
+	return len(p), nil
+}
+
+// StdLogger returns a stdlib *log.Logger whose messages are logged at level
+// through h, for libraries that take one such as http.Server.ErrorLog. It
+// adds no prefix or timestamp of its own
+func (h *HybridLogger) StdLogger(level logrus.Level) *log.Logger {
+	return log.New(&stdLogWriter{h: h, level: level}, "", 0)
+}
+
+// LineWriter returns a writer logging each Write at level through h, for
+// libraries and frameworks that log to an io.Writer one line at a time
+func (h *HybridLogger) LineWriter(level logrus.Level) io.Writer {
+	return &stdLogWriter{h: h, level: level}
+}
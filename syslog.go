@@ -0,0 +1,178 @@
+package hybridlog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Syslog facilities
+const (
+	FacilityKern   = -1 // facility 0, which as the zero value of SyslogOptions.Facility means FacilityUser
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityAuth   = 4
+	FacilityLocal0 = 16
+	FacilityLocal1 = 17
+	FacilityLocal2 = 18
+	FacilityLocal3 = 19
+	FacilityLocal4 = 20
+	FacilityLocal5 = 21
+	FacilityLocal6 = 22
+	FacilityLocal7 = 23
+)
+
+// SyslogOptions configures the syslog output
+type SyslogOptions struct {
+	Network   string           // "udp" or "tcp" for a remote RFC5424 server, empty for the local /dev/log socket
+	Address   string           // remote host:port, or a local socket path overriding the defaults
+	Facility  int              // syslog facility, defaults to FacilityUser, see FacilityKern
+	Tag       string           // APP-NAME, defaults to the program name
+	Formatter logrus.Formatter // message formatter, defaults to the file formatter
+}
+
+// syslogSeverity maps logrus levels to syslog severities
+var syslogSeverity = map[logrus.Level]int{
+	logrus.PanicLevel: 1, // alert
+	logrus.FatalLevel: 2, // crit
+	logrus.ErrorLevel: 3, // err
+	logrus.WarnLevel:  4, // warning
+	logrus.InfoLevel:  6, // info
+	logrus.DebugLevel: 7, // debug
+	logrus.TraceLevel: 7,
+}
+
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogWriter sends entries to syslog, RFC3164 over the local socket and
+// RFC5424 to remote servers. It connects lazily and redials after errors
+// with backoff, like networkWriter
+type syslogWriter struct {
+	mu       sync.Mutex
+	opts     SyslogOptions
+	hostname string
+	conn     net.Conn
+	redial   redialer
+}
+
+func newSyslogWriter(opts SyslogOptions) *syslogWriter {
+	switch opts.Facility {
+	case 0:
+		opts.Facility = FacilityUser
+	case FacilityKern:
+		opts.Facility = 0
+	}
+	if opts.Tag == "" {
+		opts.Tag = filepath.Base(os.Args[0])
+	}
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "-"
+	}
+	return &syslogWriter{opts: opts, hostname: host}
+}
+
+func (s *syslogWriter) dial() (net.Conn, error) {
+	if s.opts.Network != "" {
+		return net.DialTimeout(s.opts.Network, s.opts.Address, networkWriteTimeout)
+	}
+	sockets := localSyslogSockets
+	if s.opts.Address != "" {
+		sockets = []string{s.opts.Address}
+	}
+	var err error
+	for _, path := range sockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to local syslog: %v", err)
+}
+
+// Write sends p at info severity
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(logrus.InfoLevel, p)
+}
+
+// WriteLevel sends p with the syslog severity of level
+func (s *syslogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	msg := s.message(level, bytes.TrimRight(p, "\n"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// One retry with a fresh connection, the server may have restarted
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.redial.ready(s.network(), s.opts.Address); err != nil {
+				return 0, err
+			}
+			if s.conn, err = s.dial(); err != nil {
+				s.redial.fail()
+				return 0, err
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(networkWriteTimeout))
+		if _, err = s.conn.Write(msg); err == nil {
+			s.redial.succeeded()
+			return len(p), nil
+		}
+		s.conn.Close()
+		s.conn = nil
+		if isTimeout(err) {
+			break // stalled rather than restarted
+		}
+	}
+	s.redial.fail()
+	return 0, err
+}
+
+// network names the transport in errors
+func (s *syslogWriter) network() string {
+	if s.opts.Network == "" {
+		return "syslog"
+	}
+	return s.opts.Network
+}
+
+func (s *syslogWriter) message(level logrus.Level, p []byte) []byte {
+	pri := s.opts.Facility*8 + syslogSeverity[level]
+	now := time.Now()
+	var b bytes.Buffer
+	if s.opts.Network == "" {
+		// RFC3164, understood by every local syslog daemon
+		fmt.Fprintf(&b, "<%d>%s %s[%d]: %s\n", pri, now.Format(time.Stamp), s.opts.Tag, os.Getpid(), p)
+		return b.Bytes()
+	}
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - - %s", pri, now.Format(time.RFC3339Nano), s.hostname,
+		strings.ReplaceAll(s.opts.Tag, " ", "_"), os.Getpid(), p)
+	if s.opts.Network == "udp" {
+		return b.Bytes()
+	}
+	// RFC6587 octet counting framing for stream transports
+	return append([]byte(fmt.Sprintf("%d ", b.Len())), b.Bytes()...)
+}
+
+// Close closes the connection to syslog
+func (s *syslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
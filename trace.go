@@ -0,0 +1,29 @@
+package hybridlog
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TraceExtractor returns an extractor adding the "trace_id" and "span_id"
+// that spanIDs finds in an entry's context, so that logs correlate with
+// traces in Grafana Tempo, Jaeger and the like. OpenTelemetry isn't a
+// dependency of this package, with it spanIDs reads the span context:
+//
+//	spanIDs := func(ctx context.Context) (string, string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	}
+//	h.AddContextExtractor(hybridlog.TraceExtractor(spanIDs))
+//
+// Entries for which spanIDs reports false get neither field
+func TraceExtractor(spanIDs func(context.Context) (traceID, spanID string, ok bool)) ContextExtractor {
+	return func(ctx context.Context) logrus.Fields {
+		traceID, spanID, ok := spanIDs(ctx)
+		if !ok {
+			return nil
+		}
+		return logrus.Fields{"trace_id": traceID, "span_id": spanID}
+	}
+}
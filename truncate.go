@@ -0,0 +1,91 @@
+package hybridlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TruncatedKey marks entries whose message or fields were cut short
+const TruncatedKey = "truncated"
+
+// truncateHook cuts messages and field values longer than the limits, in
+// bytes and 0 for no limit. Values with a string form are cut as strings,
+// maps, structs, slices and pointers to them as their JSON encoding
+type truncateHook struct {
+	maxMessage int
+	maxField   int
+}
+
+func (t *truncateHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (t *truncateHook) Fire(e *logrus.Entry) error {
+	if isDropped(e) {
+		return nil
+	}
+	truncated := false
+	if t.maxMessage > 0 && len(e.Message) > t.maxMessage {
+		e.Message = truncate(e.Message, t.maxMessage)
+		truncated = true
+	}
+	if t.maxField > 0 {
+		for k, v := range e.Data {
+			var s string
+			switch v := v.(type) {
+			case string:
+				s = v
+			case []byte:
+				s = string(v)
+			case error:
+				s = v.Error()
+			case fmt.Stringer:
+				s = v.String()
+			default:
+				if !isComposite(v) {
+					continue
+				}
+				b, err := json.Marshal(v)
+				if err != nil {
+					continue
+				}
+				s = string(b)
+			}
+			if len(s) > t.maxField {
+				e.Data[k] = truncate(s, t.maxField)
+				truncated = true
+			}
+		}
+	}
+	if truncated {
+		e.Data[TruncatedKey] = true
+	}
+	return nil
+}
+
+// isComposite reports whether v is of a kind that can grow without bound,
+// scalars are never long enough to be worth encoding
+func isComposite(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
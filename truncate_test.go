@@ -0,0 +1,44 @@
+package hybridlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTruncateHookCutsEncodedValues(t *testing.T) {
+	big := map[string]string{"k": strings.Repeat("x", 100)}
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"string", "abcdef", "abcd"},
+		{"map", big, `{"k"`},
+		{"slice", []int{1, 2, 3}, "[1,2"},
+		{"pointer", &struct{ A string }{"long"}, `{"A"`},
+		{"short map", map[string]int{}, nil},
+		{"number", 123456789, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := logrus.NewEntry(logrus.New()).WithField("v", tt.value)
+			if err := (&truncateHook{maxField: 4}).Fire(e); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if _, ok := e.Data[TruncatedKey]; ok {
+					t.Fatalf("%v marked truncated", tt.value)
+				}
+				return
+			}
+			if e.Data["v"] != tt.want {
+				t.Fatalf("got %q, want %q", e.Data["v"], tt.want)
+			}
+			if e.Data[TruncatedKey] != true {
+				t.Fatal("entry not marked truncated")
+			}
+		})
+	}
+}
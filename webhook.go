@@ -0,0 +1,112 @@
+package hybridlog
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookOptions configures the webhook output, which POSTs each Error,
+// Fatal and Panic entry as JSON for alerting
+type WebhookOptions struct {
+	URL           string            // endpoint receiving the POST requests
+	Headers       map[string]string // extra request headers, e.g. Authorization
+	Formatter     logrus.Formatter  // request body formatter, defaults to JSON
+	RatePerMinute int               // entries sent per minute, defaults to 10, the rest are counted and dropped
+	Burst         int               // entries sent at once before the rate applies, defaults to RatePerMinute
+	MaxRetries    int               // retries of a failed request, defaults to 3, negative disables
+	Client        *http.Client      // defaults to a client with a 10s timeout
+}
+
+// webhookWriter rate limits entries with a token bucket, the next entry sent
+// after some were dropped carries their number in a "suppressed" field
+type webhookWriter struct {
+	*batcher
+	opts WebhookOptions
+
+	mu         sync.Mutex
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+func newWebhookWriter(opts WebhookOptions) *webhookWriter {
+	if opts.Formatter == nil {
+		opts.Formatter = &logrus.JSONFormatter{}
+	}
+	if opts.RatePerMinute <= 0 {
+		opts.RatePerMinute = 10
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = opts.RatePerMinute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	w := &webhookWriter{opts: opts, tokens: float64(opts.Burst), last: time.Now()}
+	w.batcher = newBatcher(BatchOptions{MaxEntries: 1, QueueSize: opts.Burst, MaxRetries: opts.MaxRetries}, w.send)
+	return w
+}
+
+// allow takes a token, it returns false and counts the entry when there is none
+func (w *webhookWriter) allow() (bool, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	w.tokens += now.Sub(w.last).Minutes() * float64(w.opts.RatePerMinute)
+	if w.tokens > float64(w.opts.Burst) {
+		w.tokens = float64(w.opts.Burst)
+	}
+	w.last = now
+	if w.tokens < 1 {
+		w.suppressed++
+		return false, 0
+	}
+	w.tokens--
+	suppressed := w.suppressed
+	w.suppressed = 0
+	return true, suppressed
+}
+
+// WriteEntry queues the entry unless the rate limit is exceeded
+func (w *webhookWriter) WriteEntry(e *logrus.Entry) error {
+	ok, suppressed := w.allow()
+	if !ok {
+		return nil
+	}
+	ne := *e
+	ne.Buffer = nil // the file output renders into e.Buffer
+	if suppressed > 0 {
+		ne.Data = make(logrus.Fields, len(e.Data)+1)
+		for k, v := range e.Data {
+			ne.Data[k] = v
+		}
+		ne.Data["suppressed"] = suppressed
+	}
+	b, err := w.opts.Formatter.Format(&ne)
+	if err != nil {
+		return err
+	}
+	return w.enqueue(batchRecord{time: e.Time, level: e.Level, line: append([]byte(nil), b...)})
+}
+
+func (w *webhookWriter) send(batch []batchRecord) error {
+	for _, rec := range batch {
+		req, err := http.NewRequest(http.MethodPost, w.opts.URL, bytes.NewReader(rec.line))
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range w.opts.Headers {
+			req.Header.Set(k, v)
+		}
+		if err := doHTTP(w.opts.Client, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}